@@ -0,0 +1,84 @@
+package hmac
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// requestKey is the context key of the request stored by UnaryRequestInterceptor.
+type requestKey struct{}
+
+// UnaryRequestInterceptor is a grpc.UnaryServerInterceptor storing the request in the context for AuthFunc, which does
+// not receive the request. Chain it before auth.UnaryServerInterceptor of go-grpc-middleware.
+func UnaryRequestInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(context.WithValue(ctx, requestKey{}, req), req)
+}
+
+// AuthFunc returns a go-grpc-middleware auth.AuthFunc authenticating requests using getSecret like the server
+// interceptor, the method is read from the context with grpc.Method. Unary requests are verified with the request
+// stored by UnaryRequestInterceptor, without it only the method is verified as for streams. WithFallback and WithDryRun
+// apply as for the server interceptor, the returned context holds the Identity of the request, see
+// IdentityFromContext. Scopes of methods declared with the (hmac.v1.auth) option in protoregistry.GlobalFiles are
+// checked with the GetScopes of WithScopes, required: false is not applied. Services implementing
+// auth.ServiceAuthFuncOverride can call it for the methods requiring HMAC and skip it for others.
+func AuthFunc(getSecret GetSecret, opts ...Option) func(ctx context.Context) (context.Context, error) {
+	return authFunc(getSecret, protoregistry.GlobalFiles, opts...)
+}
+
+func authFunc(getSecret GetSecret, files *protoregistry.Files, opts ...Option) func(ctx context.Context) (context.Context, error) {
+	o := newOptions(opts...)
+	s := &serverInterceptor{
+		auth:      authForSecrets(getSecret, opts...),
+		fallback:  o.fallback,
+		forwarded: o.forwarding != nil,
+		getScopes: o.getScopes,
+		dryRun:    o.dryRun,
+	}
+	scopesOf := methodScopes(files)
+	return func(ctx context.Context) (context.Context, error) {
+		method, ok := grpc.Method(ctx)
+		if !ok {
+			return nil, status.Error(codes.Internal, "hmac: missing method in context")
+		}
+		message, _, err := o.newMessage(ctx.Value(requestKey{}), method)
+		if err != nil {
+			return nil, err
+		}
+		authCtx, err := s.authenticate(ctx, message)
+		if err != nil {
+			logger.Printf("auth error on method %s: %q", method, err)
+			if !s.dryRun.allows(ctx, method, err) {
+				return nil, unauthorized(err)
+			}
+			return ctx, nil
+		}
+		if err = s.checkScopes(authCtx, method, scopesOf(method)); err != nil && !s.dryRun.allows(authCtx, method, err) {
+			return nil, err
+		}
+		return authCtx, nil
+	}
+}
+
+// KeyID returns the key id of a request authenticated with HMAC by the server interceptors or AuthFunc.
+func KeyID(ctx context.Context) (string, bool) {
+	identity, _ := IdentityFromContext(ctx)
+	return identity.KeyID, identity.KeyID != ""
+}
+
+// keyIDOf returns the key id of an authenticated request, the key id forwarded by a HTTP gateway if trusted.
+func keyIDOf(ctx context.Context, trustForwarded bool) string {
+	if trustForwarded {
+		if forwarded := metadata.ValueFromIncomingContext(ctx, "x-hmac-forwarded-key-id"); len(forwarded) > 0 && forwarded[0] != "" {
+			return forwarded[0]
+		}
+	}
+	if keyID := metadata.ValueFromIncomingContext(ctx, "x-hmac-key-id"); len(keyID) > 0 {
+		return keyID[0]
+	}
+	return ""
+}
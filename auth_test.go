@@ -0,0 +1,118 @@
+package hmac_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+func getSecret(_ context.Context, keyId string) (string, error) {
+	if keyId == "key1" {
+		return "secret1", nil
+	}
+	return "", nil
+}
+
+// implRegistrar registers services with impl as implementation.
+type implRegistrar struct {
+	grpc.ServiceRegistrar
+	impl interface{}
+}
+
+func (r implRegistrar) RegisterService(desc *grpc.ServiceDesc, _ interface{}) {
+	r.ServiceRegistrar.RegisterService(desc, r.impl)
+}
+
+// publicEcho opts out of HMAC for the streaming method with auth.ServiceAuthFuncOverride.
+type publicEcho struct {
+	authFunc auth.AuthFunc
+}
+
+func (s publicEcho) AuthFuncOverride(ctx context.Context, fullMethodName string) (context.Context, error) {
+	if fullMethodName == hmactest.EchoStreamMethod {
+		return ctx, nil
+	}
+	return s.authFunc(ctx)
+}
+
+// keyIDInterceptor fails requests without a key id in the context.
+func keyIDInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if keyID, ok := hmac.KeyID(ctx); !ok || keyID != "key1" {
+		return nil, status.Errorf(codes.Internal, "key id = %q in context, want key1", keyID)
+	}
+	return handler(ctx, req)
+}
+
+func TestAuthFunc(t *testing.T) {
+	opts := []hmac.Option{hmac.WithTimestamp(time.Minute)}
+	authFunc := hmac.AuthFunc(getSecret, opts...)
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(hmac.UnaryRequestInterceptor, auth.UnaryServerInterceptor(authFunc), keyIDInterceptor),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authFunc)),
+	}
+	client := hmac.NewClientInterceptor("key1", "secret1", opts...)
+	signed := startChained(t, serverOpts, client.ChainUnary(), client.ChainStream())
+	unsigned := startChained(t, serverOpts)
+	wrong := startChained(t, serverOpts, hmac.NewClientInterceptor("key1", "secret2", opts...).ChainUnary())
+	if _, err := hmactest.Echo(context.Background(), signed, "hello"); err != nil {
+		t.Errorf("Echo() error = %v", err)
+	}
+	if _, err := hmactest.EchoStream(context.Background(), signed, "hello"); err != nil {
+		t.Errorf("EchoStream() error = %v", err)
+	}
+	if _, err := hmactest.Echo(context.Background(), unsigned, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+	if _, err := hmactest.EchoStream(context.Background(), unsigned, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("EchoStream() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+	if _, err := hmactest.Echo(context.Background(), wrong, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestAuthFunc_withoutRequest(t *testing.T) {
+	authFunc := hmac.AuthFunc(getSecret)
+	serverOpts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(authFunc))}
+	client := hmac.NewClientInterceptor("key1", "secret1")
+	if _, err := hmactest.Echo(context.Background(), startChained(t, serverOpts, client.ChainUnary()), "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v for request signed with payload, want code %v", err, codes.Unauthenticated)
+	}
+	signMethod := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		message, _ := hmac.NewMessage(nil, method)
+		ctx = metadata.NewOutgoingContext(ctx, hmac.Sign("key1", "secret1", message))
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if _, err := hmactest.Echo(context.Background(), startChained(t, serverOpts, grpc.WithChainUnaryInterceptor(signMethod)), "hello"); err != nil {
+		t.Errorf("Echo() error = %v for request signed without payload", err)
+	}
+}
+
+func TestAuthFunc_ServiceAuthFuncOverride(t *testing.T) {
+	authFunc := hmac.AuthFunc(getSecret)
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(hmac.UnaryRequestInterceptor, auth.UnaryServerInterceptor(authFunc)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authFunc)),
+	}
+	register := func(r grpc.ServiceRegistrar) { hmactest.RegisterEcho(implRegistrar{r, publicEcho{authFunc}}) }
+	unsigned := startServer(t, register, serverOpts)
+	if _, err := hmactest.EchoStream(context.Background(), unsigned, "hello"); err != nil {
+		t.Errorf("EchoStream() error = %v for method opted out", err)
+	}
+	if _, err := hmactest.Echo(context.Background(), unsigned, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+	signed := startServer(t, register, serverOpts, hmac.NewClientInterceptor("key1", "secret1").ChainUnary())
+	if _, err := hmactest.Echo(context.Background(), signed, "hello"); err != nil {
+		t.Errorf("Echo() error = %v", err)
+	}
+}
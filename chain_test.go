@@ -0,0 +1,170 @@
+package hmac_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+// calls records the interceptors called in order.
+type calls struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (c *calls) add(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = append(c.names, name)
+}
+
+func (c *calls) reset() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := c.names
+	c.names = nil
+	return names
+}
+
+// logging returns interceptors recording the request and the code of the response.
+func (c *calls) logging() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		c.add("logging")
+		resp, err := handler(ctx, req)
+		c.add("logging:" + status.Code(err).String())
+		return resp, err
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		c.add("logging")
+		err := handler(srv, ss)
+		c.add("logging:" + status.Code(err).String())
+		return err
+	}
+	return unary, stream
+}
+
+// authFunc is a go-grpc-middleware auth.AuthFunc recording the key id authenticated by the HMAC interceptor.
+func (c *calls) authFunc(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.add("auth:" + md.Get("x-hmac-key-id")[0])
+	return ctx, nil
+}
+
+// startChained starts an Echo server with opts listening on a bufconn.Listener and returns a connection dialing it.
+func startChained(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	return startServer(t, hmactest.RegisterEcho, serverOpts, dialOpts...)
+}
+
+// startServer is like startChained registering services with register.
+func startServer(t *testing.T, register func(grpc.ServiceRegistrar), serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(serverOpts...)
+	register(server)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestServerInterceptor_Chain(t *testing.T) {
+	c := &calls{}
+	unaryLogging, streamLogging := c.logging()
+	interceptor := hmac.NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil })
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryLogging),
+		grpc.ChainStreamInterceptor(streamLogging),
+		interceptor.ChainUnary(),
+		interceptor.ChainStream(),
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(c.authFunc)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(c.authFunc)),
+	}
+	client := hmac.NewClientInterceptor("key1", "secret1")
+	signed := startChained(t, serverOpts, client.ChainUnary(), client.ChainStream())
+	unsigned := startChained(t, serverOpts)
+	tests := []struct {
+		name string
+		call func() error
+		want []string
+	}{
+		{
+			name: "SignedUnary",
+			call: func() error { _, err := hmactest.Echo(context.Background(), signed, "hello"); return err },
+			want: []string{"logging", "auth:key1", "logging:OK"},
+		},
+		{
+			name: "SignedStream",
+			call: func() error { _, err := hmactest.EchoStream(context.Background(), signed, "hello"); return err },
+			want: []string{"logging", "auth:key1", "logging:OK"},
+		},
+		{
+			name: "UnsignedUnary",
+			call: func() error { _, err := hmactest.Echo(context.Background(), unsigned, "hello"); return err },
+			want: []string{"logging", "logging:Unauthenticated"},
+		},
+		{
+			name: "UnsignedStream",
+			call: func() error { _, err := hmactest.EchoStream(context.Background(), unsigned, "hello"); return err },
+			want: []string{"logging", "logging:Unauthenticated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if wantErr := tt.want[len(tt.want)-1] != "logging:OK"; (err != nil) != wantErr {
+				t.Errorf("call error = %v, wantErr %v", err, wantErr)
+			}
+			if got := c.reset(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interceptors called = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientInterceptor_Chain(t *testing.T) {
+	interceptor := hmac.NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil })
+	client := hmac.NewClientInterceptor("key1", "secret1")
+	var signed []string
+	inspect := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		signed = md.Get("x-hmac-key-id")
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	conn := startChained(t, []grpc.ServerOption{interceptor.ChainUnary()}, client.ChainUnary(), grpc.WithChainUnaryInterceptor(inspect))
+	if _, err := hmactest.Echo(context.Background(), conn, "hello"); err != nil {
+		t.Fatalf("Echo() error = %v", err)
+	}
+	if !reflect.DeepEqual(signed, []string{"key1"}) {
+		t.Errorf("x-hmac-key-id seen by later interceptor = %v, want [key1]", signed)
+	}
+	before := startChained(t, []grpc.ServerOption{interceptor.ChainUnary()}, grpc.WithChainUnaryInterceptor(inspect), client.ChainUnary())
+	if _, err := hmactest.Echo(context.Background(), before, "hello"); err != nil {
+		t.Fatalf("Echo() error = %v", err)
+	}
+	if len(signed) != 0 {
+		t.Errorf("x-hmac-key-id seen by earlier interceptor = %v, want none", signed)
+	}
+}
@@ -0,0 +1,82 @@
+package hmac
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// channelBindingLabel is the RFC 5705 exporter label of the channel binding.
+	channelBindingLabel = "EXPORTER-go-grpc-hmac-channel-binding"
+	// channelBindingLength is the length of the exported keying material.
+	channelBindingLength = 32
+)
+
+// channelBinding returns the base64 encoded keying material exported from the TLS session of authInfo.
+func channelBinding(authInfo credentials.AuthInfo) (string, error) {
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", ErrMissingChannelBinding
+	}
+	material, err := tlsInfo.State.ExportKeyingMaterial(channelBindingLabel, nil, channelBindingLength)
+	if err != nil {
+		logger.Printf("failed to export TLS keying material: %q", err)
+		return "", ErrInvalidChannelBinding
+	}
+	defer WipeBytes(material)
+	return base64.StdEncoding.EncodeToString(material), nil
+}
+
+// verifyChannelBinding adds the channel binding of the connection the request was received on to the message.
+func (o *options) verifyChannelBinding(ctx context.Context, message *canonical) error {
+	if !o.channelBinding {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ErrMissingChannelBinding
+	}
+	binding, err := channelBinding(p.AuthInfo)
+	if err != nil {
+		return err
+	}
+	message.add("channel-binding", binding)
+	return nil
+}
+
+// channelBindingCredentials signs a call once its connection is known, as credentials.PerRPCCredentials receive the
+// credentials.AuthInfo of the connection the call is sent on.
+type channelBindingCredentials struct {
+	client  *clientInterceptor
+	message canonical
+	call    call
+}
+
+// GetRequestMetadata returns the signed metadata of the call.
+func (c *channelBindingCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	info, ok := credentials.RequestInfoFromContext(ctx)
+	if !ok {
+		return nil, errors.New("hmac: channel binding requires request info")
+	}
+	signed := c.call
+	var err error
+	if signed.channelBinding, err = channelBinding(info.AuthInfo); err != nil {
+		return nil, err
+	}
+	var buf [maxSignedPairs]string
+	pairs := c.client.opts.appendPairs(ctx, buf[:0], c.client.hmacKeyId, c.client.secret, c.message, signed)
+	md := make(map[string]string, len(pairs)/2) //nolint:mnd
+	for i := 0; i < len(pairs); i += 2 {
+		md[pairs[i]] = pairs[i+1]
+	}
+	return md, nil
+}
+
+// RequireTransportSecurity returns true, the channel binding requires TLS.
+func (c *channelBindingCredentials) RequireTransportSecurity() bool {
+	return true
+}
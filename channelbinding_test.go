@@ -0,0 +1,167 @@
+package hmac_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+// newTLSConfigs returns server and client TLS configurations using a self-signed certificate for 127.0.0.1.
+func newTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}, MinVersion: tls.VersionTLS13}
+	client := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS13}
+	return server, client
+}
+
+// recorder records the x-hmac metadata of the last unary request.
+type recorder struct {
+	mu sync.Mutex
+	md metadata.MD
+}
+
+func (r *recorder) interceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.mu.Lock()
+	r.md = metadata.MD{}
+	for k, v := range md {
+		if strings.HasPrefix(k, "x-hmac-") {
+			r.md.Set(k, v...)
+		}
+	}
+	r.mu.Unlock()
+	return handler(ctx, req)
+}
+
+func (r *recorder) recorded() metadata.MD {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.md.Copy()
+}
+
+// startTLSServer serves the Echo service on a loopback listener, recording the x-hmac metadata of each request.
+func startTLSServer(t *testing.T, tlsConfig *tls.Config, recorder *recorder, opts ...hmac.Option) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	interceptor := hmac.NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil }, opts...)
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(recorder.interceptor, interceptor.UnaryServerInterceptor),
+		interceptor.StreamInterceptor(),
+	)
+	hmactest.RegisterEcho(server)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func dialTLS(t *testing.T, addr string, tlsConfig *tls.Config, opts ...hmac.Option) *grpc.ClientConn {
+	t.Helper()
+	interceptor := hmac.NewClientInterceptor("key1", "secret1", opts...)
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		interceptor.WithUnaryInterceptor(),
+		interceptor.WithStreamInterceptor(),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestWithChannelBinding(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	recorder := &recorder{}
+	addr := startTLSServer(t, serverTLS, recorder, hmac.WithChannelBinding())
+	conn := dialTLS(t, addr, clientTLS, hmac.WithChannelBinding())
+	if _, err := hmactest.Echo(context.Background(), conn, "hello"); err != nil {
+		t.Fatalf("Echo() error = %v", err)
+	}
+	if _, err := hmactest.EchoStream(context.Background(), conn, "hello"); err != nil {
+		t.Errorf("EchoStream() error = %v", err)
+	}
+	plain, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer plain.Close()
+	if _, err = hmactest.Echo(hmactest.Replay(context.Background(), recorder.recorded()), plain, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v for request forwarded over another TLS session, want code %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestWithChannelBinding_mismatch(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	recorder := &recorder{}
+	addr := startTLSServer(t, serverTLS, recorder)
+	if _, err := hmactest.Echo(context.Background(), dialTLS(t, addr, clientTLS, hmac.WithChannelBinding()), "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v for server without channel binding, want code %v", err, codes.Unauthenticated)
+	}
+	bound := startTLSServer(t, serverTLS, recorder, hmac.WithChannelBinding())
+	if _, err := hmactest.Echo(context.Background(), dialTLS(t, bound, clientTLS), "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v for client without channel binding, want code %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestWithChannelBinding_insecure(t *testing.T) {
+	srv := hmactest.NewServer(func(context.Context, string) (string, error) { return "secret1", nil }, nil, hmac.WithChannelBinding())
+	defer srv.Close()
+	interceptor := hmac.NewClientInterceptor("key1", "secret1", hmac.WithChannelBinding())
+	conn, err := srv.Dial(interceptor.WithUnaryInterceptor(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	if _, err = hmactest.Echo(context.Background(), conn, "hello"); err == nil {
+		t.Error("Echo() expected error on insecure connection")
+	}
+}
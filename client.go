@@ -2,12 +2,14 @@ package hmac
 
 import (
 	"context"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
 
 // ClientInterceptor is a grpc client interceptor that adds HMAC authentication to outgoing requests.
+// Methods may be added to the interface, implementations outside this module should embed a ClientInterceptor.
 type ClientInterceptor interface {
 	// StreamClientInterceptor a grpc.StreamClientInterceptor that adds HMAC authentication to outgoing requests.
 	StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error)
@@ -17,16 +19,27 @@ type ClientInterceptor interface {
 	WithStreamInterceptor() grpc.DialOption
 	// WithUnaryInterceptor returns a grpc.DialOption that can be passed to grpc.Dial
 	WithUnaryInterceptor() grpc.DialOption
+	// ChainUnary returns a grpc.DialOption adding UnaryClientInterceptor to the chained unary interceptors
+	ChainUnary() grpc.DialOption
+	// ChainStream returns a grpc.DialOption adding StreamClientInterceptor to the chained stream interceptors
+	ChainStream() grpc.DialOption
 }
 
 type clientInterceptor struct {
-	hmacKeyId, hmacSecret string
+	hmacKeyId string
+	secret    signer
+	opts      options
 }
 
 // NewClientInterceptor returns a new client interceptor that adds HMAC authentication to outgoing requests.
 // The hmacKeyId and hmacSecret are used to sign the request.
-func NewClientInterceptor(hmacKeyId, hmacSecret string) ClientInterceptor {
-	return &clientInterceptor{hmacKeyId, hmacSecret}
+func NewClientInterceptor(hmacKeyId, hmacSecret string, opts ...Option) ClientInterceptor {
+	return NewSecretClientInterceptor(hmacKeyId, newStringSecret(hmacSecret), opts...)
+}
+
+// NewSecretClientInterceptor is like NewClientInterceptor using a Secret, which is not wiped by the interceptor.
+func NewSecretClientInterceptor(hmacKeyId string, secret *Secret, opts ...Option) ClientInterceptor {
+	return &clientInterceptor{hmacKeyId, secret, newOptions(opts...)}
 }
 
 // StreamClientInterceptor a grpc.StreamClientInterceptor that adds HMAC authentication to outgoing requests.
@@ -35,17 +48,20 @@ func (c *clientInterceptor) StreamClientInterceptor(ctx context.Context, desc *g
 	if err != nil {
 		return nil, err
 	}
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-hmac-key-id", c.hmacKeyId, "x-hmac-signature", String(c.hmacSecret, message))
+	ctx, opts = c.sign(ctx, messageOf(message), "", cc, opts)
 	return streamer(ctx, desc, cc, method, opts...)
 }
 
 // UnaryClientInterceptor a grpc.UnaryClientInterceptor that adds HMAC authentication to outgoing requests.
 func (c *clientInterceptor) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-	message, err := NewMessage(req, method)
+	e := requestEncoders.Get().(*requestEncoder) //nolint:forcetypeassert
+	message, digest, err := c.opts.encodeMessage(e, req, method)
 	if err != nil {
+		e.release()
 		return err
 	}
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-hmac-key-id", c.hmacKeyId, "x-hmac-signature", String(c.hmacSecret, message))
+	ctx, opts = c.sign(ctx, message, digest, cc, opts)
+	e.release()
 	return invoker(ctx, method, req, reply, cc, opts...)
 }
 
@@ -58,3 +74,60 @@ func (c *clientInterceptor) WithStreamInterceptor() grpc.DialOption {
 func (c *clientInterceptor) WithUnaryInterceptor() grpc.DialOption {
 	return grpc.WithUnaryInterceptor(c.UnaryClientInterceptor)
 }
+
+// ChainUnary returns a grpc.DialOption adding UnaryClientInterceptor to the chained unary interceptors of the
+// connection, which run in the order of the options passed to grpc.NewClient.
+func (c *clientInterceptor) ChainUnary() grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptor)
+}
+
+// ChainStream returns a grpc.DialOption adding StreamClientInterceptor to the chained stream interceptors of the
+// connection, which run in the order of the options passed to grpc.NewClient.
+func (c *clientInterceptor) ChainStream() grpc.DialOption {
+	return grpc.WithChainStreamInterceptor(c.StreamClientInterceptor)
+}
+
+// sign appends the HMAC metadata for message to the outgoing context, or with WithChannelBinding adds per RPC
+// credentials signing a copy of the message once the connection is known.
+func (c *clientInterceptor) sign(ctx context.Context, message canonical, digest string, cc *grpc.ClientConn, opts []grpc.CallOption) (context.Context, []grpc.CallOption) {
+	signed := call{authority: c.authority(cc, opts), contentDigest: digest}
+	if c.opts.channelBinding {
+		creds := &channelBindingCredentials{client: c, message: messageOf(message.String()), call: signed}
+		return ctx, append(opts, grpc.PerRPCCredentials(creds))
+	}
+	var pairs [maxSignedPairs]string
+	return metadata.AppendToOutgoingContext(ctx, c.opts.appendPairs(ctx, pairs[:0], c.hmacKeyId, c.secret, message, signed)...), opts
+}
+
+// authority returns the :authority of a call when WithAuthority is used.
+func (c *clientInterceptor) authority(cc *grpc.ClientConn, opts []grpc.CallOption) string {
+	if !c.opts.authority {
+		return ""
+	}
+	if authority := c.opts.signedAuthority(); authority != "" {
+		return authority
+	}
+	for _, opt := range opts {
+		if o, ok := opt.(grpc.AuthorityOverrideCallOption); ok {
+			return o.Authority
+		}
+	}
+	if cc == nil {
+		return ""
+	}
+	return targetAuthority(cc.Target())
+}
+
+// targetAuthority returns the default :authority gRPC uses for target, the endpoint of the target URI or localhost for
+// unix sockets. Connections created with grpc.WithAuthority need an explicit authority passed to WithAuthority.
+func targetAuthority(target string) string {
+	if strings.HasPrefix(target, "unix:") || strings.HasPrefix(target, "unix-abstract:") {
+		return "localhost"
+	}
+	if _, rest, ok := strings.Cut(target, "://"); ok {
+		// the authority of the target URI is the resolver authority, the endpoint is the path
+		_, endpoint, _ := strings.Cut(rest, "/")
+		return endpoint
+	}
+	return target
+}
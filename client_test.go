@@ -3,9 +3,11 @@ package hmac
 import (
 	"context"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestStreamClientInterceptor(t *testing.T) {
@@ -28,8 +30,8 @@ func TestStreamClientInterceptor(t *testing.T) {
 		return nil, nil
 	}
 	c := &clientInterceptor{
-		hmacKeyId:  "key1",
-		hmacSecret: "secret1",
+		hmacKeyId: "key1",
+		secret:    NewSecret([]byte("secret1")),
 	}
 	_, err := c.StreamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, "method1", handler)
 	if err != nil {
@@ -61,8 +63,8 @@ func TestUnaryClientInterceptor(t *testing.T) {
 		return nil
 	}
 	c := &clientInterceptor{
-		hmacKeyId:  "key1",
-		hmacSecret: "secret1",
+		hmacKeyId: "key1",
+		secret:    NewSecret([]byte("secret1")),
 	}
 	err := c.UnaryClientInterceptor(context.Background(), "method1", req, nil, nil, handler)
 	if err != nil {
@@ -72,3 +74,137 @@ func TestUnaryClientInterceptor(t *testing.T) {
 		t.Errorf("UnaryClientInterceptor() expected handler to be called")
 	}
 }
+
+func TestUnaryClientInterceptor_timestamp(t *testing.T) {
+	now := time.Unix(1688212800, 0)
+	handler := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		timestamp := md.Get("x-hmac-timestamp")
+		if len(timestamp) < 1 || timestamp[0] != "1688212800" {
+			t.Errorf("UnaryClientInterceptor() expected timestamp to match got %v", timestamp)
+		}
+		hmacSign := md.Get("x-hmac-signature")
+		if len(hmacSign) < 1 || hmacSign[0] != String("secret1", "method=method1;timestamp=1688212800") {
+			t.Errorf("UnaryClientInterceptor() expected signature to include timestamp")
+		}
+		return nil
+	}
+	c := NewClientInterceptor("key1", "secret1", WithClock(ClockFunc(func() time.Time { return now })), WithTimestamp(time.Minute))
+	if err := c.UnaryClientInterceptor(context.Background(), "method1", nil, nil, nil, handler); err != nil {
+		t.Fatalf("UnaryClientInterceptor() expected error to be nil got error = %v", err)
+	}
+}
+
+func TestUnaryClientInterceptor_expiry(t *testing.T) {
+	now := time.Unix(1688212800, 0)
+	clock := WithClock(ClockFunc(func() time.Time { return now }))
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		deadline time.Time
+		want     string
+	}{
+		{name: "TTL", ttl: time.Minute, want: "1688212860"},
+		{name: "EarlierDeadline", ttl: time.Minute, deadline: now.Add(time.Second), want: "1688212801"},
+		{name: "LaterDeadline", ttl: time.Minute, deadline: now.Add(time.Hour), want: "1688212860"},
+		{name: "OnlyDeadline", deadline: now.Add(time.Hour), want: "1688216400"},
+		{name: "NoDeadline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				expires := md.Get("x-hmac-expires")
+				if tt.want == "" {
+					if len(expires) > 0 {
+						t.Errorf("UnaryClientInterceptor() expected no expires got %v", expires)
+					}
+					return nil
+				}
+				if len(expires) < 1 || expires[0] != tt.want {
+					t.Errorf("UnaryClientInterceptor() expected expires %v got %v", tt.want, expires)
+				}
+				hmacSign := md.Get("x-hmac-signature")
+				if len(hmacSign) < 1 || hmacSign[0] != String("secret1", "method=method1;expires="+tt.want) {
+					t.Errorf("UnaryClientInterceptor() expected signature to include expires")
+				}
+				return nil
+			}
+			ctx := context.Background()
+			if !tt.deadline.IsZero() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, tt.deadline)
+				defer cancel()
+			}
+			c := NewClientInterceptor("key1", "secret1", clock, WithExpiry(tt.ttl))
+			if err := c.UnaryClientInterceptor(ctx, "method1", nil, nil, nil, handler); err != nil {
+				t.Fatalf("UnaryClientInterceptor() expected error to be nil got error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_targetAuthority(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"localhost:50051", "localhost:50051"},
+		{"dns:///api.example.com:443", "api.example.com:443"},
+		{"dns://8.8.8.8/api.example.com:443", "api.example.com:443"},
+		{"passthrough:///bufnet", "bufnet"},
+		{"unix:///tmp/grpc.sock", "localhost"},
+		{"unix:relative.sock", "localhost"},
+	}
+	for _, tt := range tests {
+		if got := targetAuthority(tt.target); got != tt.want {
+			t.Errorf("targetAuthority(%q) got = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestUnaryClientInterceptor_authority(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		callOpts []grpc.CallOption
+		want     string
+	}{
+		{name: "Explicit", opts: []Option{WithAuthority("api.example.com")}, callOpts: []grpc.CallOption{grpc.CallAuthority("other")}, want: "method=method1;authority=api.example.com"},
+		{name: "CallOption", opts: []Option{WithAuthority()}, callOpts: []grpc.CallOption{grpc.CallAuthority("other")}, want: "method=method1;authority=other"},
+		{name: "NoConnection", opts: []Option{WithAuthority()}, want: "method=method1;authority="},
+		{name: "Disabled", want: "method=method1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				hmacSign := md.Get("x-hmac-signature")
+				if len(hmacSign) < 1 || hmacSign[0] != String("secret1", tt.want) {
+					t.Errorf("UnaryClientInterceptor() expected signature of %q", tt.want)
+				}
+				return nil
+			}
+			c := NewClientInterceptor("key1", "secret1", tt.opts...)
+			if err := c.UnaryClientInterceptor(context.Background(), "method1", nil, nil, nil, handler, tt.callOpts...); err != nil {
+				t.Fatalf("UnaryClientInterceptor() expected error to be nil got error = %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkUnaryClientInterceptor(b *testing.B) {
+	c := NewClientInterceptor("key1", "secret1")
+	req := wrapperspb.String("gopher")
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return nil
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.UnaryClientInterceptor(ctx, "/example.UserService/GetUser", req, nil, nil, invoker); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
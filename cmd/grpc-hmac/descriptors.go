@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorFlags are the flags, named as in grpcurl, providing the descriptors of the requests.
+type descriptorFlags struct {
+	protosets   stringList
+	protos      stringList
+	importPaths stringList
+}
+
+func (d *descriptorFlags) register(fs *flag.FlagSet) {
+	fs.Var(&d.protosets, "protoset", "file with a FileDescriptorSet describing the request message, can be repeated")
+	fs.Var(&d.protos, "proto", "proto source file describing the request message, can be repeated")
+	fs.Var(&d.importPaths, "import-path", "path to search for -proto imports, can be repeated")
+}
+
+func (d *descriptorFlags) empty() bool {
+	return len(d.protosets) == 0 && len(d.protos) == 0
+}
+
+// files returns the descriptors of all -protoset and -proto files.
+func (d *descriptorFlags) files() (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+	for _, path := range d.protosets {
+		if err := registerProtoset(files, path); err != nil {
+			return nil, err
+		}
+	}
+	if len(d.protos) == 0 {
+		return files, nil
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: d.importPaths}),
+	}
+	compiled, err := compiler.Compile(context.Background(), d.protos...)
+	if err != nil {
+		return nil, err
+	}
+	for _, fd := range compiled {
+		if err = registerFile(files, fd); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func registerProtoset(files *protoregistry.Files, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	set := new(descriptorpb.FileDescriptorSet)
+	if err = proto.Unmarshal(data, set); err != nil {
+		return fmt.Errorf("failed to decode protoset %s: %w", path, err)
+	}
+	resolved, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("invalid protoset %s: %w", path, err)
+	}
+	resolved.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = registerFile(files, fd)
+		return err == nil
+	})
+	return err
+}
+
+// registerFile registers fd and its imports unless already registered.
+func registerFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// goJSON encodes m the same way encoding/json encodes the protoc-gen-go generated struct of m, which is what
+// hmac.NewMessage signs. This differs from protojson: fields use the proto name, enums and 64-bit integers are
+// numbers, oneofs are nested under the Go name of the oneof and well-known types are encoded as regular messages,
+// except google.protobuf.Struct, Value and ListValue which implement json.Marshaler using protojson.
+func goJSON(m protoreflect.Message) (json.RawMessage, error) {
+	buf := new(bytes.Buffer)
+	if err := writeMessage(buf, m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMessage(buf *bytes.Buffer, m protoreflect.Message) error {
+	if jsonMarshalers[m.Descriptor().FullName()] {
+		return writeMarshaler(buf, m)
+	}
+	fields := m.Descriptor().Fields()
+	seen := make(map[protoreflect.FullName]bool)
+	buf.WriteByte('{')
+	first := true
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var err error
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+			if seen[od.FullName()] {
+				continue
+			}
+			seen[od.FullName()] = true
+			writeKey(buf, &first, goCamelCase(string(od.Name())))
+			err = writeOneof(buf, m, od)
+		} else if present(m, fd) {
+			writeKey(buf, &first, string(fd.Name()))
+			err = writeField(buf, fd, m.Get(fd))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// jsonMarshalers are the generated types implementing json.Marshaler.
+var jsonMarshalers = map[protoreflect.FullName]bool{
+	"google.protobuf.Struct":    true,
+	"google.protobuf.Value":     true,
+	"google.protobuf.ListValue": true,
+}
+
+// writeMarshaler writes the protojson encoding of m compacted and HTML escaped, as encoding/json does for json.Marshaler.
+func writeMarshaler(buf *bytes.Buffer, m protoreflect.Message) error {
+	data, err := protojson.Marshal(m.Interface())
+	if err != nil {
+		return fmt.Errorf("json: error calling MarshalJSON for type %s: %w", m.Descriptor().FullName(), err)
+	}
+	compacted := new(bytes.Buffer)
+	if err = json.Compact(compacted, data); err != nil {
+		return err
+	}
+	json.HTMLEscape(buf, compacted.Bytes())
+	return nil
+}
+
+// present reports whether encoding/json includes the field, generated fields are tagged omitempty.
+func present(m protoreflect.Message, fd protoreflect.FieldDescriptor) bool {
+	switch {
+	case fd.IsList():
+		return m.Get(fd).List().Len() > 0
+	case fd.IsMap():
+		return m.Get(fd).Map().Len() > 0
+	case fd.Kind() == protoreflect.BytesKind:
+		// bytes fields are []byte even with explicit presence
+		return len(m.Get(fd).Bytes()) > 0
+	case fd.HasPresence():
+		// messages and scalars with explicit presence are pointers
+		return m.Has(fd)
+	default:
+		return m.Get(fd).Interface() != fd.Default().Interface()
+	}
+}
+
+func writeOneof(buf *bytes.Buffer, m protoreflect.Message, od protoreflect.OneofDescriptor) error {
+	fd := m.WhichOneof(od)
+	if fd == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	first := true
+	buf.WriteByte('{')
+	writeKey(buf, &first, goCamelCase(string(fd.Name())))
+	if err := writeSingular(buf, fd, m.Get(fd)); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeField(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		buf.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeSingular(buf, fd, list.Get(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case fd.IsMap():
+		return writeMap(buf, fd, v.Map())
+	default:
+		return writeSingular(buf, fd, v)
+	}
+}
+
+func writeMap(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, m protoreflect.Map) error {
+	if fd.MapKey().Kind() == protoreflect.BoolKind {
+		return fmt.Errorf("json: unsupported type: map[bool] for field %s", fd.FullName())
+	}
+	keys := make([]string, 0, m.Len())
+	values := make(map[string]protoreflect.Value, m.Len())
+	m.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		keys = append(keys, k.String())
+		values[k.String()] = v
+		return true
+	})
+	// encoding/json sorts map keys by their string representation
+	sort.Strings(keys)
+	first := true
+	buf.WriteByte('{')
+	for _, k := range keys {
+		writeKey(buf, &first, k)
+		if err := writeSingular(buf, fd.MapValue(), values[k]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeSingular(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	var value interface{}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if !v.Message().IsValid() {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeMessage(buf, v.Message())
+	case protoreflect.EnumKind:
+		value = int32(v.Enum())
+	case protoreflect.FloatKind:
+		value = float32(v.Float())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+		return nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+		return nil
+	default:
+		// bool, double, string and bytes encode the same as their Go values
+		value = v.Interface()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode field %s: %w", fd.FullName(), err)
+	}
+	buf.Write(data)
+	return nil
+}
+
+func writeKey(buf *bytes.Buffer, first *bool, key string) {
+	if !*first {
+		buf.WriteByte(',')
+	}
+	*first = false
+	data, _ := json.Marshal(key)
+	buf.Write(data)
+	buf.WriteByte(':')
+}
+
+// goCamelCase returns the Go name protoc-gen-go uses for a proto identifier,
+// see google.golang.org/protobuf/internal/strs.GoCamelCase.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// skip over '.' in ".{{lowercase}}"
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// skip over '_' in "_{{lowercase}}"
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
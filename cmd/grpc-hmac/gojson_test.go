@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestGoJSON(t *testing.T) {
+	st, _ := structpb.NewStruct(map[string]interface{}{"b": "<x>", "a": 1.5, "c": []interface{}{true, nil}, "d": map[string]interface{}{}})
+	tests := []struct {
+		name string
+		msg  proto.Message
+	}{
+		{"EmptyString", wrapperspb.String("")},
+		{"String", wrapperspb.String("héllo <b>&</b>")},
+		{"Int64", wrapperspb.Int64(-9007199254740993)},
+		{"UInt64", wrapperspb.UInt64(18446744073709551615)},
+		{"Float", wrapperspb.Float(0.1)},
+		{"Double", wrapperspb.Double(1e21)},
+		{"Bytes", wrapperspb.Bytes([]byte("bytes"))},
+		{"Timestamp", timestamppb.New(time.Date(2023, 7, 1, 12, 0, 0, 1, time.UTC))},
+		{"Struct", st},
+		{"Value", structpb.NewStringValue("value")},
+		{"ListValue", &structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(1)}}},
+		{"Type", &typepb.Type{
+			Name:    "type",
+			Fields:  []*typepb.Field{{Kind: typepb.Field_TYPE_STRING, Cardinality: typepb.Field_CARDINALITY_REPEATED, Number: 1, Name: "f"}, {}},
+			Oneofs:  []string{"a", "b"},
+			Syntax:  typepb.Syntax_SYNTAX_PROTO3,
+			Options: []*typepb.Option{{Name: "opt"}},
+		}},
+		{"Api", &apipb.Api{Name: "api", Methods: []*apipb.Method{{Name: "m", RequestStreaming: true}}}},
+		{"Proto2", &descriptorpb.FileDescriptorProto{
+			Name:             proto.String(""),
+			Dependency:       []string{"a.proto"},
+			PublicDependency: []int32{0},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("M"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("f"),
+					Number: proto.Int32(0),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+				}},
+			}},
+			Options: &descriptorpb.FileOptions{JavaMultipleFiles: proto.Bool(false)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			data, err := proto.Marshal(tt.msg)
+			if err != nil {
+				t.Fatalf("proto.Marshal() error = %v", err)
+			}
+			dynamic := dynamicpb.NewMessage(tt.msg.ProtoReflect().Descriptor())
+			if err = proto.Unmarshal(data, dynamic); err != nil {
+				t.Fatalf("proto.Unmarshal() error = %v", err)
+			}
+			got, err := goJSON(dynamic)
+			if err != nil {
+				t.Fatalf("goJSON() error = %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("goJSON() got = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func Test_goCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"kind":          "Kind",
+		"string_value":  "StringValue",
+		"_foo":          "XFoo",
+		"foo_bar_2":     "FooBar_2",
+		"fooBar":        "FooBar",
+		"oneof_field_1": "OneofField_1",
+	}
+	for in, want := range tests {
+		if got := goCamelCase(in); got != want {
+			t.Errorf("goCamelCase(%q) got = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestGoJSON_oneof(t *testing.T) {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("oneof.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Request"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()},
+				{Name: proto.String("user_name"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), OneofIndex: proto.Int32(0)},
+				{Name: proto.String("user_id"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), OneofIndex: proto.Int32(0)},
+				{Name: proto.String("limit"), Number: proto.Int32(4), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), OneofIndex: proto.Int32(1), Proto3Optional: proto.Bool(true)},
+			},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("user_selector")}, {Name: proto.String("_limit")}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() error = %v", err)
+	}
+	desc := file.Messages().ByName("Request")
+	fields := desc.Fields()
+	tests := []struct {
+		name string
+		set  func(m *dynamicpb.Message)
+		want string
+	}{
+		{"Unset", func(*dynamicpb.Message) {}, `{"UserSelector":null}`},
+		{"ZeroValue", func(m *dynamicpb.Message) { m.Set(fields.ByName("user_id"), protoreflect.ValueOfInt32(0)) }, `{"UserSelector":{"UserId":0}}`},
+		{"Set", func(m *dynamicpb.Message) {
+			m.Set(fields.ByName("id"), protoreflect.ValueOfInt64(1))
+			m.Set(fields.ByName("user_name"), protoreflect.ValueOfString("gopher"))
+			m.Set(fields.ByName("limit"), protoreflect.ValueOfInt32(0))
+		}, `{"id":1,"UserSelector":{"UserName":"gopher"},"limit":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := dynamicpb.NewMessage(desc)
+			tt.set(m)
+			got, err := goJSON(m)
+			if err != nil {
+				t.Fatalf("goJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("goJSON() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+// grpcurlCommand prints the -H arguments for grpcurl, the request is decoded from protojson and signed as the
+// generated struct the server receives.
+type grpcurlCommand struct {
+	descriptors descriptorFlags
+	data        string
+	keyID       string
+	secret      string
+	timestamp   timestampFlag
+}
+
+func (c *grpcurlCommand) register(fs *flag.FlagSet) {
+	c.descriptors.register(fs)
+	fs.StringVar(&c.data, "d", "", "request in the protojson format used by grpcurl -d, @ reads it from stdin")
+	fs.StringVar(&c.keyID, "key-id", "", "HMAC key id (required)")
+	secretFlag(fs, &c.secret)
+	fs.Var(&c.timestamp, "timestamp", "sign x-hmac-timestamp, now or unix seconds, for servers using hmac.WithTimestamp")
+}
+
+func (c *grpcurlCommand) run(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected exactly one argument, the method as used by grpcurl, e.g. example.UserService/GetUser")
+	}
+	if c.keyID == "" || c.secret == "" {
+		return 0, errors.New("-key-id and -secret are required")
+	}
+	if c.descriptors.empty() {
+		return 0, errors.New("-protoset or -proto is required")
+	}
+	method, err := grpcurlMethod(args[0])
+	if err != nil {
+		return 0, err
+	}
+	message, err := c.message(method, stdin)
+	if err != nil {
+		return 0, err
+	}
+	md := hmac.Sign(c.keyID, c.secret, message, c.timestamp.options()...)
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	headers := make([]string, 0, len(keys))
+	for _, k := range keys {
+		headers = append(headers, "-H "+shellQuote(k+": "+md[k][0]))
+	}
+	fmt.Fprintln(stdout, strings.Join(headers, " "))
+	return 0, nil
+}
+
+// message returns the canonical message the server computes for the request.
+func (c *grpcurlCommand) message(method string, stdin io.Reader) (string, error) {
+	files, err := c.descriptors.files()
+	if err != nil {
+		return "", err
+	}
+	desc, err := findMethod(files, method)
+	if err != nil {
+		return "", err
+	}
+	if desc.IsStreamingClient() || desc.IsStreamingServer() {
+		// the stream server interceptor does not sign the request
+		return hmac.NewMessage(nil, method)
+	}
+	data := []byte(c.data)
+	if c.data == "@" {
+		if data, err = io.ReadAll(stdin); err != nil {
+			return "", err
+		}
+	}
+	req := dynamicpb.NewMessage(desc.Input())
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err = protojson.Unmarshal(data, req); err != nil {
+			return "", fmt.Errorf("failed to decode -d as %s: %w", desc.Input().FullName(), err)
+		}
+	}
+	encoded, err := goJSON(req)
+	if err != nil {
+		return "", err
+	}
+	return hmac.NewMessage(encoded, method)
+}
+
+// grpcurlMethod converts service/method or service.method to the full method name.
+func grpcurlMethod(symbol string) (string, error) {
+	symbol = strings.TrimPrefix(symbol, "/")
+	if service, method, ok := strings.Cut(symbol, "/"); ok {
+		return "/" + service + "/" + method, nil
+	}
+	i := strings.LastIndex(symbol, ".")
+	if i <= 0 || i == len(symbol)-1 {
+		return "", fmt.Errorf("invalid method %q, expected service/method or service.method", symbol)
+	}
+	return "/" + symbol[:i] + "/" + symbol[i+1:], nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
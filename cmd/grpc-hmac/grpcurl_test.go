@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+func TestGrpcurl(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	srv := hmactest.NewServer(getSecret, nil)
+	defer srv.Close()
+	conn, err := srv.Dial()
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		stream bool
+	}{
+		{name: "Proto", args: []string{"-proto", "testdata/echo.proto", "-d", `"<gopher & co>"`, "hmactest.Echo/Echo"}},
+		{name: "DotMethod", args: []string{"-proto", "testdata/echo.proto", "-d", `"gopher"`, "hmactest.Echo.Echo"}},
+		{name: "Stdin", args: []string{"-proto", "testdata/echo.proto", "-d", "@", "hmactest.Echo/Echo"}, stdin: `"gopher"`},
+		{name: "Empty", args: []string{"-proto", "testdata/echo.proto", "hmactest.Echo/Echo"}},
+		{name: "Stream", args: []string{"-proto", "testdata/echo.proto", "-d", `"gopher"`, "hmactest.Echo/EchoStream"}, stream: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"grpcurl", "-key-id", "key1", "-secret", "secret1"}, tt.args...)
+			code, stdout, stderr := runCommand(t, tt.stdin, args...)
+			if code != 0 {
+				t.Fatalf("grpcurl exit code = %d, stderr = %s", code, stderr)
+			}
+			md := parseHeaders(t, stdout)
+			ctx := metadata.NewOutgoingContext(context.Background(), md)
+			value := requestValue(tt.args, tt.stdin)
+			if tt.stream {
+				_, err = hmactest.EchoStream(ctx, conn, value)
+			} else {
+				_, err = hmactest.Echo(ctx, conn, value)
+			}
+			if err != nil {
+				t.Errorf("server rejected grpcurl headers %s: %v", stdout, err)
+			}
+		})
+	}
+}
+
+func TestGrpcurl_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"NoMethod", []string{"-proto", "testdata/echo.proto"}, "expected exactly one argument"},
+		{"NoDescriptors", []string{"hmactest.Echo/Echo"}, "-protoset or -proto is required"},
+		{"InvalidMethod", []string{"-proto", "testdata/echo.proto", "Echo"}, `invalid method "Echo"`},
+		{"UnknownMethod", []string{"-proto", "testdata/echo.proto", "hmactest.Echo/Unknown"}, "method Unknown not found"},
+		{"InvalidData", []string{"-proto", "testdata/echo.proto", "-d", `{"unknown": 1}`, "hmactest.Echo/Echo"}, "failed to decode -d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"grpcurl", "-key-id", "key1", "-secret", "secret1"}, tt.args...)
+			code, _, stderr := runCommand(t, "", args...)
+			if code == 0 {
+				t.Errorf("grpcurl expected non zero exit code")
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("grpcurl stderr = %q, want %q", stderr, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrpcurl_timestamp(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "", "grpcurl", "-key-id", "key1", "-secret", "secret1", "-timestamp", "1688212800",
+		"-proto", "testdata/echo.proto", "hmactest.Echo/Echo")
+	if code != 0 {
+		t.Fatalf("grpcurl exit code = %d, stderr = %s", code, stderr)
+	}
+	message := "method=/hmactest.Echo/Echo;timestamp=1688212800"
+	want := "-H 'x-hmac-key-id: key1' -H 'x-hmac-signature: " + hmac.String("secret1", message) + "' -H 'x-hmac-timestamp: 1688212800'\n"
+	if stdout != want {
+		t.Errorf("grpcurl got = %q, want %q", stdout, want)
+	}
+}
+
+var headerPattern = regexp.MustCompile(`-H '([^:]+): ([^']+)'`)
+
+func parseHeaders(t *testing.T, out string) metadata.MD {
+	t.Helper()
+	md := metadata.MD{}
+	for _, m := range headerPattern.FindAllStringSubmatch(out, -1) {
+		md.Append(m[1], m[2])
+	}
+	if len(md) == 0 {
+		t.Fatalf("no headers in %q", out)
+	}
+	return md
+}
+
+// requestValue returns the google.protobuf.StringValue sent by grpcurl for the -d flag in args.
+func requestValue(args []string, stdin string) string {
+	data := ""
+	for i, arg := range args {
+		if arg == "-d" {
+			data = args[i+1]
+		}
+	}
+	if data == "@" {
+		data = stdin
+	}
+	return strings.Trim(data, `"`)
+}
+
+func TestGrpcurl_importPath(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "", "grpcurl", "-key-id", "key1", "-secret", "secret1",
+		"-import-path", "../../example", "-proto", "example.proto", "-d", `{"name": "unknown"}`, "example.UserService/GetUser")
+	if code != 0 {
+		t.Fatalf("grpcurl exit code = %d, stderr = %s", code, stderr)
+	}
+	message := `request={"name":"unknown"};method=/example.UserService/GetUser`
+	want := "-H 'x-hmac-key-id: key1' -H 'x-hmac-signature: " + hmac.String("secret1", message) + "'\n"
+	if stdout != want {
+		t.Errorf("grpcurl got = %q, want %q", stdout, want)
+	}
+}
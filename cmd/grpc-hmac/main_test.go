@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	code := run(args, strings.NewReader(stdin), stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSign(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "", "sign", "-key-id", "key1", "-secret", "secret1", "-method", "/test.Service/Method", "-json", `{"name": "gopher"}`)
+	if code != 0 {
+		t.Fatalf("sign exit code = %d, stderr = %s", code, stderr)
+	}
+	message := `request={"name":"gopher"};method=/test.Service/Method`
+	want := "message: " + message + "\nx-hmac-key-id: key1\nx-hmac-signature: " + hmac.String("secret1", message) + "\n"
+	if stdout != want {
+		t.Errorf("sign got = %q, want %q", stdout, want)
+	}
+}
+
+func TestSign_timestamp(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "", "sign", "-key-id", "key1", "-secret", "secret1", "-method", "/test.Service/Method", "-timestamp", "1688212800")
+	if code != 0 {
+		t.Fatalf("sign exit code = %d, stderr = %s", code, stderr)
+	}
+	message := "method=/test.Service/Method;timestamp=1688212800"
+	want := "message: " + message + "\nx-hmac-key-id: key1\nx-hmac-signature: " + hmac.String("secret1", message) + "\nx-hmac-timestamp: 1688212800\n"
+	if stdout != want {
+		t.Errorf("sign got = %q, want %q", stdout, want)
+	}
+}
+
+func TestSign_binary(t *testing.T) {
+	dir := t.TempDir()
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto)}}
+	protoset := writeProto(t, dir, "wrappers.protoset", set)
+	req := wrapperspb.String("<gopher>")
+	binary := writeProto(t, dir, "request.bin", req)
+	code, stdout, stderr := runCommand(t, "", "sign", "-key-id", "key1", "-secret", "secret1", "-method", "/test.Service/Method",
+		"-binary", binary, "-protoset", protoset, "-type", "google.protobuf.StringValue")
+	if code != 0 {
+		t.Fatalf("sign exit code = %d, stderr = %s", code, stderr)
+	}
+	message, _ := hmac.NewMessage(req, "/test.Service/Method")
+	if !strings.HasPrefix(stdout, "message: "+message+"\n") {
+		t.Errorf("sign got = %q, want message %q", stdout, message)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	message := `request={"name":"gopher"};method=/test.Service/Method`
+	signature := "x-hmac-signature: " + hmac.String("secret1", message)
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{name: "Valid", args: []string{"-H", "x-hmac-key-id: key1", "-H", signature}, want: "signature is valid"},
+		{name: "ExpectedKeyID", args: []string{"-key-id", "key1", "-H", "x-hmac-key-id: key1", "-H", signature}, want: "signature is valid"},
+		{name: "WrongKeyID", args: []string{"-key-id", "key2", "-H", "x-hmac-key-id: key1", "-H", signature}, wantCode: 1, want: "verification failed: invalid x-hmac-key-id"},
+		{name: "MissingSignature", args: []string{"-H", "x-hmac-key-id: key1"}, wantCode: 1, want: "verification failed: missing x-hmac-signature metadata"},
+		{name: "Expired", args: []string{"-H", "x-hmac-key-id: key1", "-H", signature, "-H", "x-hmac-expires: 1"}, wantCode: 1, want: "message: " + message + ";expires=1\nverification failed: expired x-hmac-expires"},
+		{name: "TimestampOutsideSkew", args: []string{"-H", "x-hmac-key-id: key1", "-H", signature, "-H", "x-hmac-timestamp: 1"}, wantCode: 1, want: "verification failed: invalid x-hmac-timestamp"},
+		{
+			name:     "WrongSignature",
+			args:     []string{"-H", "x-hmac-key-id: key1", "-H", "x-hmac-signature: wrong"},
+			wantCode: 1,
+			want:     "verification failed: invalid x-hmac-signature\nexpected " + signature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"verify", "-secret", "secret1", "-method", "/test.Service/Method", "-json", "-"}, tt.args...)
+			code, stdout, stderr := runCommand(t, `{"name":"gopher"}`, args...)
+			if code != tt.wantCode {
+				t.Errorf("verify exit code = %d, want %d, stderr = %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("verify got = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_derivedKeys(t *testing.T) {
+	message := `request={"name":"gopher"};method=/test.Service/Method`
+	md := hmac.Sign("key1", "secret1", message, hmac.WithDerivedKeys())
+	date := "x-hmac-date: " + md.Get("x-hmac-date")[0]
+	signature := "x-hmac-signature: " + md.Get("x-hmac-signature")[0]
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{name: "Valid", args: []string{"-H", "x-hmac-key-id: key1", "-H", date, "-H", signature}, want: "signature is valid"},
+		{name: "InvalidDate", args: []string{"-H", "x-hmac-key-id: key1", "-H", "x-hmac-date: 20000101", "-H", signature}, wantCode: 1, want: "verification failed: invalid x-hmac-date"},
+		{
+			name:     "WrongSignature",
+			args:     []string{"-H", "x-hmac-key-id: key1", "-H", date, "-H", "x-hmac-signature: wrong"},
+			wantCode: 1,
+			want:     "verification failed: invalid x-hmac-signature\nexpected " + signature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"verify", "-secret", "secret1", "-method", "/test.Service/Method", "-json", `{"name":"gopher"}`}, tt.args...)
+			code, stdout, stderr := runCommand(t, "", args...)
+			if code != tt.wantCode {
+				t.Errorf("verify exit code = %d, want %d, stderr = %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("verify got = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_authority(t *testing.T) {
+	message := `request={"name":"gopher"};method=/test.Service/Method`
+	md := hmac.Sign("key1", "secret1", message, hmac.WithAuthority("api.example.com"))
+	signature := "x-hmac-signature: " + md.Get("x-hmac-signature")[0]
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{name: "Valid", args: []string{"-authority", "api.example.com"}, want: message + ";authority=api.example.com\nsignature is valid"},
+		{
+			name:     "OtherAuthority",
+			args:     []string{"-authority", "staging.example.com"},
+			wantCode: 1,
+			want:     "verification failed: invalid x-hmac-signature\nexpected x-hmac-signature: " + hmac.String("secret1", message+";authority=staging.example.com"),
+		},
+		{name: "WithoutAuthority", wantCode: 1, want: "verification failed: invalid x-hmac-signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"verify", "-secret", "secret1", "-method", "/test.Service/Method", "-json", `{"name":"gopher"}`, "-H", "x-hmac-key-id: key1", "-H", signature}, tt.args...)
+			code, stdout, stderr := runCommand(t, "", args...)
+			if code != tt.wantCode {
+				t.Errorf("verify exit code = %d, want %d, stderr = %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("verify got = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_contentDigest(t *testing.T) {
+	dir := t.TempDir()
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto)}}
+	protoset := writeProto(t, dir, "wrappers.protoset", set)
+	binary := writeProto(t, dir, "request.bin", wrapperspb.String("gopher"))
+	other := writeProto(t, dir, "other.bin", wrapperspb.String("other"))
+	md, err := hmac.SignRequest("key1", "secret1", wrapperspb.String("gopher"), "/test.Service/Method", hmac.WithContentDigest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := []string{
+		"-H", "x-hmac-key-id: key1",
+		"-H", "x-hmac-content-digest: " + md.Get("x-hmac-content-digest")[0],
+		"-H", "x-hmac-signature: " + md.Get("x-hmac-signature")[0],
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{name: "Valid", args: []string{"-binary", binary}, want: "signature is valid"},
+		{name: "OtherRequest", args: []string{"-binary", other}, wantCode: 1, want: "verification failed: invalid x-hmac-content-digest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"verify", "-secret", "secret1", "-method", "/test.Service/Method", "-protoset", protoset, "-type", "google.protobuf.StringValue"}, tt.args...)
+			code, stdout, stderr := runCommand(t, "", append(args, headers...)...)
+			if code != tt.wantCode {
+				t.Errorf("verify exit code = %d, want %d, stderr = %s", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("verify got = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+	t.Run("JSON", func(t *testing.T) {
+		_, _, stderr := runCommand(t, "", append([]string{"verify", "-secret", "secret1", "-method", "/test.Service/Method", "-json", `{"value":"gopher"}`}, headers...)...)
+		if !strings.Contains(stderr, "x-hmac-content-digest requires the request as -binary") {
+			t.Errorf("verify stderr = %q", stderr)
+		}
+	})
+}
+
+func TestRun_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"NoCommand", nil, "usage: grpc-hmac"},
+		{"UnknownCommand", []string{"unknown"}, `unknown command "unknown"`},
+		{"MissingMethod", []string{"sign", "-key-id", "key1", "-secret", "secret1"}, "-method is required"},
+		{"MissingSecret", []string{"sign", "-key-id", "key1", "-method", "/a/b", "-secret", ""}, "-key-id and -secret are required"},
+		{"InvalidJSON", []string{"sign", "-key-id", "key1", "-secret", "s", "-method", "/a/b", "-json", "{"}, "-json is not valid JSON"},
+		{"BinaryWithoutProtoset", []string{"sign", "-key-id", "key1", "-secret", "s", "-method", "/a/b", "-binary", "-"}, "-binary requires -protoset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCommand(t, "", tt.args...)
+			if code == 0 {
+				t.Errorf("run() expected non zero exit code")
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("run() stderr = %q, want %q", stderr, tt.want)
+			}
+		})
+	}
+}
+
+func writeProto(t *testing.T, dir, name string, m proto.Message) string {
+	t.Helper()
+	data, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return path
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+// requestFlags are the flags describing the request shared by sign and verify.
+type requestFlags struct {
+	descriptors descriptorFlags
+	method      string
+	json        string
+	binary      string
+	messageType string
+	stdin       io.Reader
+}
+
+func (r *requestFlags) register(fs *flag.FlagSet) {
+	r.descriptors.register(fs)
+	fs.StringVar(&r.method, "method", "", "full method name, e.g. /example.UserService/GetUser (required)")
+	fs.StringVar(&r.json, "json", "", "request as encoded by encoding/json, @file reads it from file, - from stdin")
+	fs.StringVar(&r.binary, "binary", "", "file with the binary proto request, - reads it from stdin (requires -protoset or -proto)")
+	fs.StringVar(&r.messageType, "type", "", "full name of the request message, defaults to the input type of -method")
+}
+
+// message returns the canonical message hmac.NewMessage computes for the request.
+func (r *requestFlags) message() (string, error) {
+	if r.method == "" {
+		return "", errors.New("-method is required")
+	}
+	if r.json != "" && r.binary != "" {
+		return "", errors.New("only one of -json and -binary can be used")
+	}
+	req, err := r.request()
+	if err != nil {
+		return "", err
+	}
+	if req == nil {
+		return hmac.NewMessage(nil, r.method)
+	}
+	return hmac.NewMessage(req, r.method)
+}
+
+func (r *requestFlags) request() (json.RawMessage, error) {
+	switch {
+	case r.json != "":
+		data, err := r.read(r.json, true)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(data) {
+			return nil, errors.New("-json is not valid JSON")
+		}
+		return data, nil
+	case r.binary != "":
+		data, err := r.read(r.binary, false)
+		if err != nil {
+			return nil, err
+		}
+		return r.decodeBinary(data)
+	default:
+		return nil, nil
+	}
+}
+
+// read returns the value of a flag that can refer to stdin with - or, if at is set, to a file with @file.
+func (r *requestFlags) read(value string, at bool) ([]byte, error) {
+	switch {
+	case value == "-":
+		return io.ReadAll(r.stdin)
+	case at && strings.HasPrefix(value, "@"):
+		return os.ReadFile(value[1:])
+	case at:
+		return []byte(value), nil
+	default:
+		return os.ReadFile(value)
+	}
+}
+
+// digestMessage returns the message referencing the content digest of the -binary request, as signed by clients using
+// hmac.WithContentDigest.
+func (r *requestFlags) digestMessage() (string, error) {
+	if r.method == "" {
+		return "", errors.New("-method is required")
+	}
+	if r.binary == "" {
+		return "", errors.New("x-hmac-content-digest requires the request as -binary")
+	}
+	data, err := r.read(r.binary, false)
+	if err != nil {
+		return "", err
+	}
+	msg, err := r.decodeProto(data)
+	if err != nil {
+		return "", err
+	}
+	digest, err := hmac.ContentDigest(msg)
+	if err != nil {
+		return "", err
+	}
+	return "content-digest=" + digest + ";method=" + r.method, nil
+}
+
+func (r *requestFlags) decodeBinary(data []byte) (json.RawMessage, error) {
+	msg, err := r.decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	return goJSON(msg)
+}
+
+// decodeProto decodes the binary proto request using the descriptors of the flags.
+func (r *requestFlags) decodeProto(data []byte) (*dynamicpb.Message, error) {
+	if r.descriptors.empty() {
+		return nil, errors.New("-binary requires -protoset or -proto")
+	}
+	files, err := r.descriptors.files()
+	if err != nil {
+		return nil, err
+	}
+	desc, err := findRequestType(files, r.method, r.messageType)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err = proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", desc.FullName(), err)
+	}
+	return msg, nil
+}
+
+// findRequestType returns the descriptor of messageType or, if empty, of the input type of method.
+func findRequestType(files *protoregistry.Files, method, messageType string) (protoreflect.MessageDescriptor, error) {
+	if messageType == "" {
+		md, err := findMethod(files, method)
+		if err != nil {
+			return nil, err
+		}
+		return md.Input(), nil
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("message %s: %w", messageType, err)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", messageType)
+	}
+	return md, nil
+}
+
+// findMethod returns the descriptor of the full method name.
+func findMethod(files *protoregistry.Files, method string) (protoreflect.MethodDescriptor, error) {
+	service, rpc, ok := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid full method name %q", method)
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", service, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(rpc))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", rpc, service)
+	}
+	return md, nil
+}
+
+// timestampFlag is either empty, now or unix seconds.
+type timestampFlag struct {
+	set  bool
+	unix int64
+}
+
+func (t *timestampFlag) String() string {
+	if !t.set {
+		return ""
+	}
+	return strconv.FormatInt(t.unix, 10)
+}
+
+func (t *timestampFlag) Set(value string) error {
+	if value == "now" {
+		t.set, t.unix = true, time.Now().Unix()
+		return nil
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.New("must be now or unix seconds")
+	}
+	t.set, t.unix = true, unix
+	return nil
+}
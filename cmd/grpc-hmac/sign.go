@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+type signCommand struct {
+	req       requestFlags
+	keyID     string
+	secret    string
+	timestamp timestampFlag
+}
+
+func (c *signCommand) register(fs *flag.FlagSet) {
+	c.req.register(fs)
+	fs.StringVar(&c.keyID, "key-id", "", "HMAC key id (required)")
+	secretFlag(fs, &c.secret)
+	fs.Var(&c.timestamp, "timestamp", "sign x-hmac-timestamp, now or unix seconds, for servers using hmac.WithTimestamp")
+}
+
+func (c *signCommand) run(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if c.keyID == "" || c.secret == "" {
+		return 0, errors.New("-key-id and -secret are required")
+	}
+	if len(args) > 0 {
+		return 0, fmt.Errorf("unexpected arguments %v", args)
+	}
+	c.req.stdin = stdin
+	message, err := c.req.message()
+	if err != nil {
+		return 0, err
+	}
+	md := hmac.Sign(c.keyID, c.secret, message, c.timestamp.options()...)
+	if c.timestamp.set {
+		message += ";timestamp=" + c.timestamp.String()
+	}
+	fmt.Fprintf(stdout, "message: %s\n", message)
+	printMetadata(stdout, md)
+	return 0, nil
+}
+
+// options returns the hmac.Option signing the timestamp, if set.
+func (t *timestampFlag) options() []hmac.Option {
+	if !t.set {
+		return nil
+	}
+	now := time.Unix(t.unix, 0)
+	return []hmac.Option{hmac.WithClock(hmac.ClockFunc(func() time.Time { return now })), hmac.WithTimestamp(time.Second)}
+}
+
+func printMetadata(w io.Writer, md metadata.MD) {
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range md[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+}
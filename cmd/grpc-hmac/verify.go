@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+const defaultMaxSkew = 5 * time.Minute
+
+type verifyCommand struct {
+	req       requestFlags
+	headers   headerFlag
+	keyID     string
+	secret    string
+	maxSkew   time.Duration
+	authority string
+}
+
+func (c *verifyCommand) register(fs *flag.FlagSet) {
+	c.req.register(fs)
+	fs.Var(&c.headers, "H", "metadata sent by the client as 'key: value', can be repeated")
+	fs.StringVar(&c.keyID, "key-id", "", "expected HMAC key id, any key id is accepted when empty")
+	secretFlag(fs, &c.secret)
+	fs.DurationVar(&c.maxSkew, "max-skew", defaultMaxSkew, "allowed skew of x-hmac-timestamp, checked when the header is present")
+	fs.StringVar(&c.authority, "authority", "", ":authority of the call, for servers using hmac.WithAuthority")
+}
+
+func (c *verifyCommand) run(args []string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if c.secret == "" {
+		return 0, errors.New("-secret is required")
+	}
+	if len(args) > 0 {
+		return 0, fmt.Errorf("unexpected arguments %v", args)
+	}
+	c.req.stdin = stdin
+	md := c.headers.md
+	if c.authority != "" {
+		md = md.Copy()
+		md.Set(":authority", c.authority)
+	}
+	message, err := c.message(md)
+	if err != nil {
+		return 0, err
+	}
+	getSecret := func(_ context.Context, keyID string) (string, error) {
+		if c.keyID != "" && keyID != c.keyID {
+			return "", nil
+		}
+		return c.secret, nil
+	}
+	err = hmac.Verify(context.Background(), md, message, getSecret, c.options(md)...)
+	message = signedMessage(md, message)
+	fmt.Fprintf(stdout, "message: %s\n", message)
+	if err == nil {
+		fmt.Fprintln(stdout, "signature is valid")
+		return 0, nil
+	}
+	fmt.Fprintf(stdout, "verification failed: %s\n", status.Convert(err).Message())
+	if errors.Is(err, hmac.ErrInvalidHmacSignature) {
+		fmt.Fprintf(stdout, "expected x-hmac-signature: %s\n", c.expectedSignature(md, message))
+	}
+	return 1, nil
+}
+
+// message returns the message of the request, referencing its content digest if the client sent x-hmac-content-digest.
+func (c *verifyCommand) message(md metadata.MD) (string, error) {
+	if len(md.Get("x-hmac-content-digest")) > 0 {
+		return c.req.digestMessage()
+	}
+	return c.req.message()
+}
+
+// options returns the hmac.Options of the modes the client signed the request with, as told by its metadata.
+func (c *verifyCommand) options(md metadata.MD) []hmac.Option {
+	var opts []hmac.Option
+	if len(md.Get("x-hmac-timestamp")) > 0 {
+		opts = append(opts, hmac.WithTimestamp(c.maxSkew))
+	}
+	if len(md.Get("x-hmac-date")) > 0 {
+		opts = append(opts, hmac.WithDerivedKeys())
+	}
+	if len(md.Get(":authority")) > 0 {
+		opts = append(opts, hmac.WithAuthority())
+	}
+	if len(md.Get("x-hmac-content-digest")) > 0 {
+		opts = append(opts, hmac.WithContentDigest())
+	}
+	return opts
+}
+
+// signedFields are the message fields and the metadata they are read from, in the order they are signed.
+var signedFields = [][2]string{{"timestamp", "x-hmac-timestamp"}, {"expires", "x-hmac-expires"}, {"authority", ":authority"}}
+
+// signedMessage returns message with the fields of md appended in the order they are signed.
+func signedMessage(md metadata.MD, message string) string {
+	for _, field := range signedFields {
+		if value := md.Get(field[1]); len(value) > 0 {
+			message += ";" + field[0] + "=" + value[0]
+		}
+	}
+	return message
+}
+
+// expectedSignature returns the x-hmac-signature of message, using the key derived for x-hmac-date if present.
+func (c *verifyCommand) expectedSignature(md metadata.MD, message string) string {
+	date := md.Get("x-hmac-date")
+	if len(date) == 0 {
+		return hmac.String(c.secret, message)
+	}
+	secret := hmac.NewSecret([]byte(c.secret))
+	defer secret.Wipe()
+	derived := hmac.DeriveKey(secret, date[0], c.req.method)
+	defer hmac.WipeBytes(derived)
+	key := hmac.NewSecret(derived)
+	defer key.Wipe()
+	return string(key.Sign(message))
+}
+
+// headerFlag collects repeated 'key: value' flags into metadata.
+type headerFlag struct {
+	md metadata.MD
+}
+
+func (h *headerFlag) String() string {
+	return ""
+}
+
+func (h *headerFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("invalid header %s, expected 'key: value'", strconv.Quote(value))
+	}
+	if h.md == nil {
+		h.md = metadata.MD{}
+	}
+	h.md.Append(strings.TrimSpace(key), strings.TrimSpace(val))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	contextPackage  = protogen.GoImportPath("context")
+	hmacPackage     = protogen.GoImportPath("github.com/yogeshlonkar/go-grpc-hmac")
+	metadataPackage = protogen.GoImportPath("google.golang.org/grpc/metadata")
+)
+
+// methodNames returns the name used in the helpers of each method, the method name unless services of the same Go
+// package have methods with the same name, then prefixed with the service name.
+func methodNames(gen *protogen.Plugin) map[*protogen.Method]string {
+	counts := make(map[protogen.GoImportPath]map[string]int)
+	for _, file := range gen.Files {
+		if counts[file.GoImportPath] == nil {
+			counts[file.GoImportPath] = make(map[string]int)
+		}
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				counts[file.GoImportPath][method.GoName]++
+			}
+		}
+	}
+	names := make(map[*protogen.Method]string)
+	for _, file := range gen.Files {
+		for _, service := range file.Services {
+			for _, method := range service.Methods {
+				names[method] = method.GoName
+				if counts[file.GoImportPath][method.GoName] > 1 {
+					names[method] = service.GoName + method.GoName
+				}
+			}
+		}
+	}
+	return names
+}
+
+// generateFile generates the helpers of the methods of file.
+func generateFile(gen *protogen.Plugin, file *protogen.File, names map[*protogen.Method]string) {
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_hmac.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-hmac. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	for _, service := range file.Services {
+		for _, method := range service.Methods {
+			generateMethod(g, method, names[method])
+		}
+	}
+}
+
+// generateMethod generates the Sign and Verify helpers of method.
+func generateMethod(g *protogen.GeneratedFile, method *protogen.Method, name string) {
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent.Desc.FullName(), method.Desc.Name())
+	streaming := method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer()
+	reqParam, reqArg := "req *"+g.QualifiedGoIdent(method.Input.GoIdent)+", ", "req"
+	if streaming {
+		reqParam, reqArg = "", "nil"
+	}
+	md := g.QualifiedGoIdent(metadataPackage.Ident("MD"))
+	option := g.QualifiedGoIdent(hmacPackage.Ident("Option"))
+	g.P()
+	if streaming {
+		g.P("// Sign", name, "Request returns the HMAC metadata authenticating a stream of ", fullMethod, ", see hmac.SignRequest.")
+	} else {
+		g.P("// Sign", name, "Request returns the HMAC metadata authenticating req to ", fullMethod, ", see hmac.SignRequest.")
+	}
+	g.P("func Sign", name, "Request(keyID, secret string, ", reqParam, "opts ...", option, ") (", md, ", error) {")
+	g.P("return ", hmacPackage.Ident("SignRequest"), "(keyID, secret, ", reqArg, ", ", fmt.Sprintf("%q", fullMethod), ", opts...)")
+	g.P("}")
+	g.P()
+	if streaming {
+		g.P("// Verify", name, "Request checks md authenticates a stream of ", fullMethod, ", see hmac.VerifyRequest.")
+	} else {
+		g.P("// Verify", name, "Request checks md authenticates req to ", fullMethod, ", see hmac.VerifyRequest.")
+	}
+	g.P("// The scopes of the (hmac.v1.auth) method option are not checked, only the signature.")
+	g.P("func Verify", name, "Request(ctx ", contextPackage.Ident("Context"), ", md ", md, ", ", reqParam,
+		"getSecret ", hmacPackage.Ident("GetSecret"), ", opts ...", option, ") error {")
+	g.P("return ", hmacPackage.Ident("VerifyRequest"), "(ctx, md, ", reqArg, ", ", fmt.Sprintf("%q", fullMethod), ", getSecret, opts...)")
+	g.P("}")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/yogeshlonkar/go-grpc-hmac/cmd/protoc-gen-go-hmac/internal/testpb"
+)
+
+func TestGenerate(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{testpb.File_echo_proto.Path()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(wrapperspb.File_google_protobuf_wrappers_proto),
+			protodesc.ToFileDescriptorProto(testpb.File_echo_proto),
+		},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = generate(gen); err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+	res := gen.Response()
+	if res.Error != nil || len(res.File) != 1 || res.File[0].GetName() != "echo_hmac.pb.go" {
+		t.Fatalf("generate() response = %v, want echo_hmac.pb.go", res)
+	}
+	want, err := os.ReadFile("internal/testpb/echo_hmac.pb.go")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got := res.File[0].GetContent(); got != string(want) {
+		t.Errorf("generate() got\n%s\nwant\n%s", got, want)
+	}
+}
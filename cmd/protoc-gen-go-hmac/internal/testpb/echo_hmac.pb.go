@@ -0,0 +1,55 @@
+// Code generated by protoc-gen-go-hmac. DO NOT EDIT.
+// source: echo.proto
+
+package testpb
+
+import (
+	context "context"
+	go_grpc_hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	metadata "google.golang.org/grpc/metadata"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// SignEchoServiceEchoRequest returns the HMAC metadata authenticating req to /testpb.v1.EchoService/Echo, see hmac.SignRequest.
+func SignEchoServiceEchoRequest(keyID, secret string, req *EchoRequest, opts ...go_grpc_hmac.Option) (metadata.MD, error) {
+	return go_grpc_hmac.SignRequest(keyID, secret, req, "/testpb.v1.EchoService/Echo", opts...)
+}
+
+// VerifyEchoServiceEchoRequest checks md authenticates req to /testpb.v1.EchoService/Echo, see hmac.VerifyRequest.
+// The scopes of the (hmac.v1.auth) method option are not checked, only the signature.
+func VerifyEchoServiceEchoRequest(ctx context.Context, md metadata.MD, req *EchoRequest, getSecret go_grpc_hmac.GetSecret, opts ...go_grpc_hmac.Option) error {
+	return go_grpc_hmac.VerifyRequest(ctx, md, req, "/testpb.v1.EchoService/Echo", getSecret, opts...)
+}
+
+// SignEchoStreamRequest returns the HMAC metadata authenticating a stream of /testpb.v1.EchoService/EchoStream, see hmac.SignRequest.
+func SignEchoStreamRequest(keyID, secret string, opts ...go_grpc_hmac.Option) (metadata.MD, error) {
+	return go_grpc_hmac.SignRequest(keyID, secret, nil, "/testpb.v1.EchoService/EchoStream", opts...)
+}
+
+// VerifyEchoStreamRequest checks md authenticates a stream of /testpb.v1.EchoService/EchoStream, see hmac.VerifyRequest.
+// The scopes of the (hmac.v1.auth) method option are not checked, only the signature.
+func VerifyEchoStreamRequest(ctx context.Context, md metadata.MD, getSecret go_grpc_hmac.GetSecret, opts ...go_grpc_hmac.Option) error {
+	return go_grpc_hmac.VerifyRequest(ctx, md, nil, "/testpb.v1.EchoService/EchoStream", getSecret, opts...)
+}
+
+// SignUpperRequest returns the HMAC metadata authenticating req to /testpb.v1.EchoService/Upper, see hmac.SignRequest.
+func SignUpperRequest(keyID, secret string, req *wrapperspb.StringValue, opts ...go_grpc_hmac.Option) (metadata.MD, error) {
+	return go_grpc_hmac.SignRequest(keyID, secret, req, "/testpb.v1.EchoService/Upper", opts...)
+}
+
+// VerifyUpperRequest checks md authenticates req to /testpb.v1.EchoService/Upper, see hmac.VerifyRequest.
+// The scopes of the (hmac.v1.auth) method option are not checked, only the signature.
+func VerifyUpperRequest(ctx context.Context, md metadata.MD, req *wrapperspb.StringValue, getSecret go_grpc_hmac.GetSecret, opts ...go_grpc_hmac.Option) error {
+	return go_grpc_hmac.VerifyRequest(ctx, md, req, "/testpb.v1.EchoService/Upper", getSecret, opts...)
+}
+
+// SignOtherServiceEchoRequest returns the HMAC metadata authenticating req to /testpb.v1.OtherService/Echo, see hmac.SignRequest.
+func SignOtherServiceEchoRequest(keyID, secret string, req *EchoRequest, opts ...go_grpc_hmac.Option) (metadata.MD, error) {
+	return go_grpc_hmac.SignRequest(keyID, secret, req, "/testpb.v1.OtherService/Echo", opts...)
+}
+
+// VerifyOtherServiceEchoRequest checks md authenticates req to /testpb.v1.OtherService/Echo, see hmac.VerifyRequest.
+// The scopes of the (hmac.v1.auth) method option are not checked, only the signature.
+func VerifyOtherServiceEchoRequest(ctx context.Context, md metadata.MD, req *EchoRequest, getSecret go_grpc_hmac.GetSecret, opts ...go_grpc_hmac.Option) error {
+	return go_grpc_hmac.VerifyRequest(ctx, md, req, "/testpb.v1.OtherService/Echo", getSecret, opts...)
+}
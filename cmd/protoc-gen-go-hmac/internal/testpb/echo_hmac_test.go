@@ -0,0 +1,56 @@
+package testpb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+func getSecret(context.Context, string) (string, error) {
+	return "secret1", nil
+}
+
+func TestSignEchoServiceEchoRequest(t *testing.T) {
+	req := &EchoRequest{Message: "hello"}
+	md, err := SignEchoServiceEchoRequest("key1", "secret1", req)
+	if err != nil {
+		t.Fatalf("SignEchoServiceEchoRequest() error = %v", err)
+	}
+	if err = VerifyEchoServiceEchoRequest(context.Background(), md, req, getSecret); err != nil {
+		t.Errorf("VerifyEchoServiceEchoRequest() error = %v", err)
+	}
+	if err = VerifyOtherServiceEchoRequest(context.Background(), md, req, getSecret); !errors.Is(err, hmac.ErrInvalidHmacSignature) {
+		t.Errorf("VerifyOtherServiceEchoRequest() error = %v for another method, want %v", err, hmac.ErrInvalidHmacSignature)
+	}
+	if err = VerifyEchoServiceEchoRequest(context.Background(), md, &EchoRequest{Message: "bye"}, getSecret); !errors.Is(err, hmac.ErrInvalidHmacSignature) {
+		t.Errorf("VerifyEchoServiceEchoRequest() error = %v for another request, want %v", err, hmac.ErrInvalidHmacSignature)
+	}
+}
+
+func TestSignUpperRequest_contentDigest(t *testing.T) {
+	req := wrapperspb.String("hello")
+	md, err := SignUpperRequest("key1", "secret1", req, hmac.WithContentDigest())
+	if err != nil {
+		t.Fatalf("SignUpperRequest() error = %v", err)
+	}
+	if len(md.Get("x-hmac-content-digest")) != 1 {
+		t.Errorf("SignUpperRequest() metadata = %v, want x-hmac-content-digest", md)
+	}
+	if err = VerifyUpperRequest(context.Background(), md, req, getSecret, hmac.WithContentDigest()); err != nil {
+		t.Errorf("VerifyUpperRequest() error = %v", err)
+	}
+}
+
+func TestSignEchoStreamRequest(t *testing.T) {
+	md, err := SignEchoStreamRequest("key1", "secret1")
+	if err != nil {
+		t.Fatalf("SignEchoStreamRequest() error = %v", err)
+	}
+	if err = VerifyEchoStreamRequest(context.Background(), md, getSecret); err != nil {
+		t.Errorf("VerifyEchoStreamRequest() error = %v", err)
+	}
+}
@@ -0,0 +1,6 @@
+// Package testpb holds the services used to test the helpers generated by protoc-gen-go-hmac.
+package testpb
+
+//go:generate go build -o protoc-gen-go-hmac ../..
+//go:generate protoc --plugin=protoc-gen-go-hmac --go_out=. --go_opt=paths=source_relative --go-hmac_out=. --go-hmac_opt=paths=source_relative echo.proto
+//go:generate rm protoc-gen-go-hmac
@@ -0,0 +1,37 @@
+// Command protoc-gen-go-hmac is a protoc plugin generating typed helpers to sign and verify requests of each method
+// of a service out of band, e.g. in queue workers or webhooks, with the full method name used by the go-grpc-hmac
+// interceptors.
+//
+// For each method it generates, in a file named <name>_hmac.pb.go next to the output of protoc-gen-go:
+//
+//	func Sign<Method>Request(keyID, secret string, req *<Input>, opts ...hmac.Option) (metadata.MD, error)
+//	func Verify<Method>Request(ctx context.Context, md metadata.MD, req *<Input>, getSecret hmac.GetSecret, opts ...hmac.Option) error
+//
+// The req parameter is omitted for streaming methods, which sign only the method. If services of a package have
+// methods with the same name the service name is prepended, e.g. Sign<Service><Method>Request.
+//
+// Usage:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative --go-hmac_out=. --go-hmac_opt=paths=source_relative example.proto
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	protogen.Options{}.Run(generate)
+}
+
+// generate adds the helpers of the files to generate to gen.
+func generate(gen *protogen.Plugin) error {
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	names := methodNames(gen)
+	for _, file := range gen.Files {
+		if file.Generate && len(file.Services) > 0 {
+			generateFile(gen, file, names)
+		}
+	}
+	return nil
+}
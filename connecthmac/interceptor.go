@@ -0,0 +1,204 @@
+// Package connecthmac provides connect.Interceptor implementations of the go-grpc-hmac client and server interceptors
+// for connectrpc.com/connect. Requests are signed and verified like gRPC calls, with the procedure as full method and
+// the x-hmac metadata as headers, so Connect clients and handlers interoperate with the gRPC interceptors.
+package connecthmac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+// ErrChannelBinding is returned by clients using hmac.WithChannelBinding, which Connect clients do not support.
+var ErrChannelBinding = errors.New("connecthmac: channel binding is not supported")
+
+// NewClientInterceptor returns a connect.Interceptor that signs outgoing requests with hmacKeyId and hmacSecret, see
+// hmac.NewClientInterceptor.
+func NewClientInterceptor(hmacKeyId, hmacSecret string, opts ...hmac.Option) connect.Interceptor {
+	return Client(hmac.NewClientInterceptor(hmacKeyId, hmacSecret, opts...))
+}
+
+// NewHandlerInterceptor returns a connect.Interceptor that authenticates requests using getSecret, see
+// hmac.NewServerInterceptor.
+func NewHandlerInterceptor(getSecret hmac.GetSecret, opts ...hmac.Option) connect.Interceptor {
+	return Handler(hmac.NewServerInterceptor(getSecret, opts...))
+}
+
+// Client returns a connect.Interceptor signing outgoing requests with interceptor, e.g. an interceptor created with
+// hmac.NewSecretClientInterceptor or hmac.NewEd25519ClientInterceptor. hmac.WithAuthority signs only the authority
+// passed to it, hmac.WithChannelBinding is not supported.
+func Client(interceptor hmac.ClientInterceptor) connect.Interceptor {
+	return &clientInterceptor{interceptor}
+}
+
+// Handler returns a connect.Interceptor authenticating requests with interceptor, e.g. an interceptor created with
+// hmac.NewSecretServerInterceptor or hmac.NewEd25519ServerInterceptor, including its ignored methods. Handlers read
+// the request headers as incoming metadata and the authenticated identity with hmac.IdentityFromContext.
+// hmac.WithAuthority and hmac.WithChannelBinding are not supported.
+func Handler(interceptor hmac.ServerInterceptor) connect.Interceptor {
+	return &handlerInterceptor{interceptor}
+}
+
+type clientInterceptor struct {
+	interceptor hmac.ClientInterceptor
+}
+
+// WrapUnary signs unary requests.
+func (c *clientInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		var res connect.AnyResponse
+		invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if err := setHeaders(ctx, req.Header(), opts); err != nil {
+				return err
+			}
+			var err error
+			res, err = next(ctx, req)
+			return err
+		}
+		if err := c.interceptor.UnaryClientInterceptor(ctx, req.Spec().Procedure, req.Any(), nil, nil, invoker); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// WrapStreamingClient signs the headers of streams before the first message is sent.
+func (c *clientInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, setHeaders(ctx, conn.RequestHeader(), opts)
+		}
+		if _, err := c.interceptor.StreamClientInterceptor(ctx, &grpc.StreamDesc{}, nil, spec.Procedure, streamer); err != nil {
+			return &failedClientConn{conn, err}
+		}
+		return conn
+	}
+}
+
+// WrapStreamingHandler returns next, clients do not handle streams.
+func (c *clientInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// setHeaders copies the x-hmac metadata of the outgoing context to the request headers.
+func setHeaders(ctx context.Context, header http.Header, opts []grpc.CallOption) error {
+	for _, opt := range opts {
+		if _, ok := opt.(grpc.PerRPCCredsCallOption); ok {
+			return ErrChannelBinding
+		}
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, v := range md {
+		if strings.HasPrefix(k, "x-hmac-") {
+			header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	return nil
+}
+
+// failedClientConn is a stream that could not be signed, sending and receiving fail with err.
+type failedClientConn struct {
+	connect.StreamingClientConn
+	err error
+}
+
+func (c *failedClientConn) Send(interface{}) error {
+	return c.err
+}
+
+func (c *failedClientConn) Receive(interface{}) error {
+	return c.err
+}
+
+type handlerInterceptor struct {
+	interceptor hmac.ServerInterceptor
+}
+
+// WrapUnary authenticates unary requests.
+func (h *handlerInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		var res connect.AnyResponse
+		var handled bool
+		handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			handled = true
+			var err error
+			res, err = next(ctx, req)
+			return res, err
+		}
+		info := &grpc.UnaryServerInfo{FullMethod: req.Spec().Procedure}
+		if _, err := h.interceptor.UnaryServerInterceptor(incomingContext(ctx, req.Header()), req.Any(), info, handler); err != nil {
+			return nil, handlerError(err, handled)
+		}
+		return res, nil
+	}
+}
+
+// WrapStreamingClient returns next, handlers do not send streams.
+func (h *handlerInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler authenticates the headers of streams.
+func (h *handlerInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		stream := &serverStream{ctx: incomingContext(ctx, conn.RequestHeader())}
+		var handled bool
+		handler := func(_ interface{}, ss grpc.ServerStream) error {
+			handled = true
+			return next(ss.Context(), conn)
+		}
+		spec := conn.Spec()
+		info := &grpc.StreamServerInfo{
+			FullMethod:     spec.Procedure,
+			IsClientStream: spec.StreamType&connect.StreamTypeClient != 0,
+			IsServerStream: spec.StreamType&connect.StreamTypeServer != 0,
+		}
+		if err := h.interceptor.StreamServerInterceptor(nil, stream, info, handler); err != nil {
+			return handlerError(err, handled)
+		}
+		return nil
+	}
+}
+
+// incomingContext returns ctx with the request headers as incoming metadata.
+func incomingContext(ctx context.Context, header http.Header) context.Context {
+	md := make(metadata.MD, len(header))
+	for k, v := range header {
+		md[strings.ToLower(k)] = v
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// handlerError converts errors of the server interceptor to connect errors, errors of the handler are returned as is.
+func handlerError(err error, handled bool) error {
+	if handled {
+		return err
+	}
+	s := status.Convert(err)
+	return connect.NewError(connect.Code(s.Code()), errors.New(s.Message()))
+}
+
+// serverStream provides the context of a Connect stream to the server interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
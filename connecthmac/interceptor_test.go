@@ -0,0 +1,198 @@
+package connecthmac
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+func getSecret(_ context.Context, keyId string) (string, error) {
+	if keyId == "key1" {
+		return "secret1", nil
+	}
+	return "", nil
+}
+
+// newHandler returns the Echo service as Connect handlers using interceptor.
+func newHandler(interceptor connect.Interceptor) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(hmactest.EchoMethod, connect.NewUnaryHandler(hmactest.EchoMethod,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if len(md.Get("x-hmac-key-id")) == 0 {
+				return nil, connect.NewError(connect.CodeInternal, errors.New("missing incoming metadata"))
+			}
+			return connect.NewResponse(req.Msg), nil
+		}, connect.WithInterceptors(interceptor)))
+	mux.Handle(hmactest.EchoStreamMethod, connect.NewServerStreamHandler(hmactest.EchoStreamMethod,
+		func(_ context.Context, req *connect.Request[wrapperspb.StringValue], stream *connect.ServerStream[wrapperspb.StringValue]) error {
+			return stream.Send(req.Msg)
+		}, connect.WithInterceptors(interceptor)))
+	return mux
+}
+
+// h2cClient returns a HTTP client using HTTP/2 without TLS as required by gRPC.
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func echo(ctx context.Context, client *http.Client, baseURL string, opts ...connect.ClientOption) (string, error) {
+	unary := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](client, baseURL+hmactest.EchoMethod, opts...)
+	res, err := unary.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hello")))
+	if err != nil {
+		return "", err
+	}
+	return res.Msg.GetValue(), nil
+}
+
+func echoStream(ctx context.Context, client *http.Client, baseURL string, opts ...connect.ClientOption) (string, error) {
+	streaming := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](client, baseURL+hmactest.EchoStreamMethod, opts...)
+	stream, err := streaming.CallServerStream(ctx, connect.NewRequest(wrapperspb.String("hello")))
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	if !stream.Receive() {
+		return "", stream.Err()
+	}
+	return stream.Msg().GetValue(), nil
+}
+
+func TestConnect(t *testing.T) {
+	opts := []hmac.Option{hmac.WithTimestamp(time.Minute), hmac.WithExpiry(time.Minute)}
+	server := httptest.NewServer(newHandler(NewHandlerInterceptor(getSecret, opts...)))
+	defer server.Close()
+	tests := []struct {
+		name        string
+		interceptor connect.Interceptor
+		want        connect.Code
+	}{
+		{name: "Signed", interceptor: NewClientInterceptor("key1", "secret1", opts...)},
+		{name: "WrongSecret", interceptor: NewClientInterceptor("key1", "secret2", opts...), want: connect.CodeUnauthenticated},
+		{name: "UnknownKeyID", interceptor: NewClientInterceptor("key2", "secret1", opts...), want: connect.CodeUnauthenticated},
+		{name: "MissingTimestamp", interceptor: NewClientInterceptor("key1", "secret1"), want: connect.CodeUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := echo(context.Background(), server.Client(), server.URL, connect.WithInterceptors(tt.interceptor))
+			if connect.CodeOf(err) != tt.want && (err != nil || tt.want != 0) {
+				t.Errorf("echo() error = %v, want code %v", err, tt.want)
+			}
+			if err == nil && got != "hello" {
+				t.Errorf("echo() got = %v, want hello", got)
+			}
+			got, err = echoStream(context.Background(), server.Client(), server.URL, connect.WithInterceptors(tt.interceptor))
+			if connect.CodeOf(err) != tt.want && (err != nil || tt.want != 0) {
+				t.Errorf("echoStream() error = %v, want code %v", err, tt.want)
+			}
+			if err == nil && got != "hello" {
+				t.Errorf("echoStream() got = %v, want hello", got)
+			}
+		})
+	}
+}
+
+func TestConnect_ignoredMethods(t *testing.T) {
+	interceptor := hmac.NewServerInterceptor(getSecret)
+	interceptor.IgnoredMethods(hmactest.EchoStreamMethod)
+	server := httptest.NewServer(newHandler(Handler(interceptor)))
+	defer server.Close()
+	if _, err := echoStream(context.Background(), server.Client(), server.URL); err != nil {
+		t.Errorf("echoStream() error = %v for ignored method", err)
+	}
+	if _, err := echo(context.Background(), server.Client(), server.URL); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("echo() error = %v, want code %v", err, connect.CodeUnauthenticated)
+	}
+}
+
+func TestConnect_channelBinding(t *testing.T) {
+	server := httptest.NewServer(newHandler(NewHandlerInterceptor(getSecret)))
+	defer server.Close()
+	interceptor := connect.WithInterceptors(NewClientInterceptor("key1", "secret1", hmac.WithChannelBinding()))
+	if _, err := echo(context.Background(), server.Client(), server.URL, interceptor); !errors.Is(err, ErrChannelBinding) {
+		t.Errorf("echo() error = %v, want %v", err, ErrChannelBinding)
+	}
+	if _, err := echoStream(context.Background(), server.Client(), server.URL, interceptor); !errors.Is(err, ErrChannelBinding) {
+		t.Errorf("echoStream() error = %v, want %v", err, ErrChannelBinding)
+	}
+}
+
+// TestConnect_gRPCServer calls a gRPC server with the gRPC server interceptor from a Connect client.
+func TestConnect_gRPCServer(t *testing.T) {
+	opts := []hmac.Option{hmac.WithTimestamp(time.Minute), hmac.WithDerivedKeys()}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	interceptor := hmac.NewServerInterceptor(getSecret, opts...)
+	server := grpc.NewServer(interceptor.UnaryInterceptor(), interceptor.StreamInterceptor())
+	hmactest.RegisterEcho(server)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	defer server.Stop()
+	baseURL := "http://" + lis.Addr().String()
+	signed := []connect.ClientOption{connect.WithGRPC(), connect.WithInterceptors(NewClientInterceptor("key1", "secret1", opts...))}
+	if got, err := echo(context.Background(), h2cClient(), baseURL, signed...); err != nil || got != "hello" {
+		t.Errorf("echo() got = %v, %v, want hello", got, err)
+	}
+	if got, err := echoStream(context.Background(), h2cClient(), baseURL, signed...); err != nil || got != "hello" {
+		t.Errorf("echoStream() got = %v, %v, want hello", got, err)
+	}
+	if _, err := echo(context.Background(), h2cClient(), baseURL, connect.WithGRPC()); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("echo() error = %v, want code %v", err, connect.CodeUnauthenticated)
+	}
+}
+
+// TestConnect_gRPCClient calls Connect handlers from a gRPC client with the gRPC client interceptor.
+func TestConnect_gRPCClient(t *testing.T) {
+	opts := []hmac.Option{hmac.WithTimestamp(time.Minute), hmac.WithDerivedKeys()}
+	server := httptest.NewServer(h2c.NewHandler(newHandler(NewHandlerInterceptor(getSecret, opts...)), &http2.Server{}))
+	defer server.Close()
+	dial := func(interceptor hmac.ClientInterceptor) *grpc.ClientConn {
+		conn, err := grpc.NewClient(server.Listener.Addr().String(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			interceptor.WithUnaryInterceptor(),
+			interceptor.WithStreamInterceptor(),
+		)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+	conn := dial(hmac.NewClientInterceptor("key1", "secret1", opts...))
+	if got, err := hmactest.Echo(context.Background(), conn, "hello"); err != nil || got != "hello" {
+		t.Errorf("Echo() got = %v, %v, want hello", got, err)
+	}
+	if got, err := hmactest.EchoStream(context.Background(), conn, "hello"); err != nil || got != "hello" {
+		t.Errorf("EchoStream() got = %v, %v, want hello", got, err)
+	}
+	wrong := dial(hmac.NewClientInterceptor("key1", "secret2", opts...))
+	if _, err := hmactest.Echo(context.Background(), wrong, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+}
@@ -0,0 +1,91 @@
+package hmac
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// derivedKeyInfo prefixes the HKDF info of derived keys.
+	derivedKeyInfo = "go-grpc-hmac/v1/"
+	// dateLayout is the format of the x-hmac-date metadata, the UTC date of the derived key.
+	dateLayout = "20060102"
+	// maxDerivedKeys bounds the derived keys cache, it is cleared once full.
+	maxDerivedKeys = 1024
+)
+
+// DeriveKey returns the key derived from secret for date, formatted as YYYYMMDD, and the full gRPC method.
+// The key is HKDF-Expand (RFC 5869) with SHA-512/256, secret as the pseudorandom key and the info
+// "go-grpc-hmac/v1/<date><method>", e.g. "go-grpc-hmac/v1/20240102/example.UserService/GetUser". The extract step is
+// skipped as allowed by RFC 5869 section 3.3, so the secret is only ever used as HMAC key.
+// It returns nil once secret is wiped.
+func DeriveKey(secret *Secret, date, method string) []byte {
+	// the derived key is a single hash length so HKDF-Expand is HMAC(secret, info || 0x01)
+	return secret.sum(derivedKeyInfo + date + method + "\x01")
+}
+
+// derivedKeys caches the keys derived by WithDerivedKeys, keys of dates more than a day from the current date are
+// dropped.
+type derivedKeys struct {
+	mu    sync.Mutex
+	today string
+	keys  map[derivedKeyScope]*Secret
+}
+
+type derivedKeyScope struct {
+	secret       *Secret
+	date, method string
+}
+
+func newDerivedKeys() *derivedKeys {
+	return &derivedKeys{keys: make(map[derivedKeyScope]*Secret)}
+}
+
+// key returns the Secret derived from secret for date and method, now is used to expire cached keys. It returns false
+// once secret is wiped, no key is derived or cached then.
+func (d *derivedKeys) key(secret *Secret, date, method string, now time.Time) (*Secret, bool) {
+	scope := derivedKeyScope{secret, date, method}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire(now)
+	if key, ok := d.keys[scope]; ok {
+		return key, true
+	}
+	derived := DeriveKey(secret, date, method)
+	if derived == nil {
+		return nil, false
+	}
+	defer WipeBytes(derived)
+	key := NewSecret(derived)
+	if len(d.keys) >= maxDerivedKeys {
+		d.keys = make(map[derivedKeyScope]*Secret)
+	}
+	d.keys[scope] = key
+	return key, true
+}
+
+// expire drops keys of dates the server no longer accepts when the date changes. Dropped keys are not wiped as they
+// might still be used by concurrent requests.
+func (d *derivedKeys) expire(now time.Time) {
+	today := now.UTC().Format(dateLayout)
+	if today == d.today {
+		return
+	}
+	d.today = today
+	valid := validDates(now)
+	for scope := range d.keys {
+		if scope.date != valid[0] && scope.date != valid[1] && scope.date != valid[2] {
+			delete(d.keys, scope)
+		}
+	}
+}
+
+// validDates returns the dates accepted by the server at now, yesterday, today and tomorrow in UTC.
+func validDates(now time.Time) [3]string {
+	now = now.UTC()
+	return [3]string{
+		now.AddDate(0, 0, -1).Format(dateLayout),
+		now.Format(dateLayout),
+		now.AddDate(0, 0, 1).Format(dateLayout),
+	}
+}
@@ -0,0 +1,143 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeriveKey(t *testing.T) {
+	for _, secret := range []string{"secret", string(bytes.Repeat([]byte{'s'}, sha512.BlockSize+1))} {
+		want := make([]byte, sha512.Size256)
+		info := "go-grpc-hmac/v1/20230701/example.UserService/GetUser"
+		if _, err := io.ReadFull(hkdf.Expand(sha512.New512_256, []byte(secret), []byte(info)), want); err != nil {
+			t.Fatalf("hkdf.Expand() error = %v", err)
+		}
+		if got := DeriveKey(NewSecret([]byte(secret)), "20230701", "/example.UserService/GetUser"); !bytes.Equal(got, want) {
+			t.Errorf("DeriveKey() got = %x, want %x", got, want)
+		}
+	}
+	wiped := NewSecret([]byte("secret"))
+	wiped.Wipe()
+	if got := DeriveKey(wiped, "20230701", "/example.UserService/GetUser"); got != nil {
+		t.Errorf("DeriveKey() of wiped secret got = %x, want nil", got)
+	}
+}
+
+func Test_authForSecrets_derivedKeys(t *testing.T) {
+	now := time.Date(2023, 7, 1, 23, 59, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	message := "request={};method=/example.UserService/GetUser"
+	signed := func(date, method string) context.Context {
+		key := string(DeriveKey(NewSecret([]byte("secret")), date, method))
+		return metadata.NewIncomingContext(context.Background(), metadata.MD{
+			"x-hmac-key-id":    []string{"key-id"},
+			"x-hmac-date":      []string{date},
+			"x-hmac-signature": []string{String(key, message)},
+		})
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context //nolint:containedctx
+		want error
+	}{
+		{
+			"MissingDate",
+			metadata.NewIncomingContext(context.Background(), metadata.MD{"x-hmac-signature": []string{"signature"}, "x-hmac-key-id": []string{"key-id"}}),
+			ErrMissingHmacDate,
+		},
+		{"InvalidDate", signed("2023-07-01", "/example.UserService/GetUser"), ErrInvalidHmacDate},
+		{"TooOld", signed("20230629", "/example.UserService/GetUser"), ErrInvalidHmacDate},
+		{"TooNew", signed("20230703", "/example.UserService/GetUser"), ErrInvalidHmacDate},
+		{"OtherMethod", signed("20230701", "/example.UserService/ListUsers"), ErrInvalidHmacSignature},
+		{"Yesterday", signed("20230630", "/example.UserService/GetUser"), nil},
+		{"Today", signed("20230701", "/example.UserService/GetUser"), nil},
+		{"Tomorrow", signed("20230702", "/example.UserService/GetUser"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := authForSecrets(getSecret, WithClock(clock), WithDerivedKeys())
+			if got := auth(tt.ctx, message); tt.want != got && !errors.Is(got, tt.want) { //nolint:errorlint
+				t.Errorf("auth() return got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignVerify_derivedKeys(t *testing.T) {
+	clock := ClockFunc(func() time.Time { return time.Unix(1688212800, 0) })
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	message := "method=/example.UserService/GetUser"
+	md := Sign("key-id", "secret", message, WithClock(clock), WithDerivedKeys())
+	key := string(DeriveKey(NewSecret([]byte("secret")), "20230701", "/example.UserService/GetUser"))
+	want := metadata.Pairs("x-hmac-key-id", "key-id", "x-hmac-date", "20230701", "x-hmac-signature", String(key, message))
+	if !reflect.DeepEqual(md, want) {
+		t.Errorf("Sign() got = %v, want %v", md, want)
+	}
+	if err := Verify(context.Background(), md, message, getSecret, WithClock(clock), WithDerivedKeys()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := Verify(context.Background(), md, message, getSecret, WithClock(clock)); !errors.Is(err, ErrInvalidHmacSignature) {
+		t.Errorf("Verify() without derived keys error = %v, want %v", err, ErrInvalidHmacSignature)
+	}
+}
+
+func Test_derivedKeys_key(t *testing.T) {
+	secret := NewSecret([]byte("secret"))
+	now := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	d := newDerivedKeys()
+	key, _ := d.key(secret, "20230701", "/svc/Method", now)
+	if got, _ := d.key(secret, "20230701", "/svc/Method", now); got != key {
+		t.Error("key() did not return the cached key")
+	}
+	if got, _ := d.key(secret, "20230701", "/svc/Other", now); got == key {
+		t.Error("key() returned the same key for another method")
+	}
+	d.key(secret, "20230702", "/svc/Method", now.AddDate(0, 0, 1))
+	d.key(secret, "20230703", "/svc/Method", now.AddDate(0, 0, 2))
+	if len(d.keys) != 2 {
+		t.Errorf("key() kept %d keys, want 2 after the date changed twice", len(d.keys))
+	}
+	wiped := NewSecret([]byte("secret"))
+	wiped.Wipe()
+	if got, ok := d.key(wiped, "20230703", "/svc/Method", now.AddDate(0, 0, 2)); ok || got != nil {
+		t.Errorf("key() of wiped secret got = %v, %v, want nil, false", got, ok)
+	}
+	if len(d.keys) != 2 {
+		t.Errorf("key() kept %d keys, want 2 after deriving from a wiped secret", len(d.keys))
+	}
+}
+
+func TestNewSecretServerInterceptor_derivedKeysWipedSecret(t *testing.T) {
+	clock := ClockFunc(func() time.Time { return time.Unix(1688212800, 0) })
+	wiped := NewSecret([]byte("secret"))
+	wiped.Wipe()
+	getKey := func(context.Context, string) (*Secret, error) { return wiped, nil }
+	s := NewSecretServerInterceptor(getKey, WithClock(clock), WithDerivedKeys())
+	message := "method=/s/M"
+	md := metadata.MD{
+		"x-hmac-key-id":    []string{"key-id"},
+		"x-hmac-date":      []string{"20230701"},
+		"x-hmac-signature": []string{String("", message)},
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil } //nolint:nilnil
+	if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/s/M"}, handler); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("UnaryServerInterceptor() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+	if err := VerifySecret(context.Background(), md, message, getKey, WithClock(clock), WithDerivedKeys()); !errors.Is(err, ErrInvalidHmacSignature) {
+		t.Errorf("VerifySecret() error = %v, want %v", err, ErrInvalidHmacSignature)
+	}
+}
@@ -0,0 +1,83 @@
+package hmac
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// contentDigestPrefix is the algorithm prefix of x-hmac-content-digest, in the format of RFC 9530 Content-Digest.
+	contentDigestPrefix = "sha-256=:"
+	// maxPooledBuffer is the capacity above which marshal buffers are not kept for reuse.
+	maxPooledBuffer = 4 << 20
+)
+
+// buffers holds marshal buffers reused between requests.
+var buffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// ContentDigest returns the x-hmac-content-digest of req, the SHA-256 of its deterministic protobuf encoding as
+// "sha-256=:<base64>:". The protobuf API only marshals into a byte slice, so the encoding is marshaled into a pooled
+// buffer and hashed afterwards, buffers larger than 4 MiB are left to the garbage collector.
+func ContentDigest(req proto.Message) (string, error) {
+	buf := buffers.Get().(*[]byte) //nolint:forcetypeassert
+	defer func() {
+		if cap(*buf) <= maxPooledBuffer {
+			buffers.Put(buf)
+		}
+	}()
+	data, err := proto.MarshalOptions{Deterministic: true}.MarshalAppend((*buf)[:0], req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	*buf = data
+	sum := sha256.Sum256(data)
+	return contentDigestPrefix + base64.StdEncoding.EncodeToString(sum[:]) + ":", nil
+}
+
+// newMessage returns the message of a request and, with WithContentDigest, the content digest of proto requests
+// referenced by the message instead of the request.
+func (o *options) newMessage(req interface{}, method string) (string, string, error) {
+	msg, ok := req.(proto.Message)
+	if !o.contentDigest || !ok {
+		message, err := NewMessage(req, method)
+		return message, "", err
+	}
+	digest, err := ContentDigest(msg)
+	if err != nil {
+		return "", "", err
+	}
+	return "content-digest=" + digest + ";method=" + method, digest, nil
+}
+
+// encodeMessage is like newMessage, encoding requests not referenced by their content digest with e. The head of the
+// returned message is only valid until e is released.
+func (o *options) encodeMessage(e *requestEncoder, req interface{}, method string) (canonical, string, error) {
+	if _, ok := req.(proto.Message); req == nil || (o.contentDigest && ok) {
+		message, digest, err := o.newMessage(req, method)
+		return messageOf(message), digest, err
+	}
+	if err := e.encode(req); err != nil {
+		return canonical{}, "", err
+	}
+	return canonical{head: e.buf.Bytes(), message: method}, "", nil
+}
+
+// verifyContentDigest checks x-hmac-content-digest, if present, matches the digest referenced by message.
+func (o *options) verifyContentDigest(md metadata.MD, message *canonical) error {
+	header := getFirst(md, "x-hmac-content-digest")
+	if !o.contentDigest || header == "" {
+		return nil
+	}
+	digest, _, _ := strings.Cut(strings.TrimPrefix(message.message, "content-digest="), ";")
+	if message.head != nil || !strings.HasPrefix(message.message, "content-digest=") || digest != header {
+		logger.Printf("content digest %s does not match request", header)
+		return ErrInvalidContentDigest
+	}
+	return nil
+}
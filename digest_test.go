@@ -0,0 +1,124 @@
+package hmac
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestContentDigest(t *testing.T) {
+	req, _ := structpb.NewStruct(map[string]interface{}{"b": 1, "a": "x", "c": []interface{}{true}})
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	sum := sha256.Sum256(data)
+	want := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	for i := 0; i < 3; i++ {
+		if got, err := ContentDigest(req); err != nil || got != want {
+			t.Errorf("ContentDigest() got = %v, %v, want %v", got, err, want)
+		}
+	}
+}
+
+func Test_options_newMessage(t *testing.T) {
+	o := newOptions(WithContentDigest())
+	req := wrapperspb.String("gopher")
+	digest, _ := ContentDigest(req)
+	message, got, err := o.newMessage(req, "/svc/Method")
+	if err != nil || got != digest || message != "content-digest="+digest+";method=/svc/Method" {
+		t.Errorf("newMessage() got = %v, %v, %v", message, got, err)
+	}
+	plain := &struct{ Name string }{"gopher"}
+	want, _ := NewMessage(plain, "/svc/Method")
+	if message, got, err = o.newMessage(plain, "/svc/Method"); err != nil || got != "" || message != want {
+		t.Errorf("newMessage() of non proto request got = %v, %v, %v, want %v", message, got, err, want)
+	}
+}
+
+func TestWithContentDigest(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	server := NewServerInterceptor(getSecret, WithContentDigest())
+	tests := []struct {
+		name   string
+		opts   []Option
+		tamper func(md metadata.MD)
+		want   error
+	}{
+		{name: "Valid", opts: []Option{WithContentDigest()}},
+		{name: "TamperedDigest", opts: []Option{WithContentDigest()}, tamper: func(md metadata.MD) { md.Set("x-hmac-content-digest", "sha-256=:AAAA:") }, want: ErrUnauthorized},
+		{name: "ClientWithoutDigest", want: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := func(ctx context.Context, method string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				if tt.tamper != nil {
+					tt.tamper(md)
+				}
+				handler := func(context.Context, interface{}) (interface{}, error) { return req, nil }
+				// the server receives a copy of the request
+				received := proto.Clone(req.(proto.Message)) //nolint:forcetypeassert
+				_, err := server.UnaryServerInterceptor(metadata.NewIncomingContext(ctx, md), received, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+				return err
+			}
+			client := NewClientInterceptor("key1", "secret1", tt.opts...)
+			req := wrapperspb.String("gopher")
+			if err := client.UnaryClientInterceptor(context.Background(), "/svc/Method", req, nil, nil, invoker); !errors.Is(err, tt.want) {
+				t.Errorf("UnaryServerInterceptor() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_contentDigest(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	o := newOptions(WithContentDigest())
+	message, digest, _ := o.newMessage(wrapperspb.String("gopher"), "/svc/Method")
+	md := Sign("key1", "secret1", message)
+	md.Set("x-hmac-content-digest", digest)
+	if err := Verify(context.Background(), md, message, getSecret, WithContentDigest()); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	md.Set("x-hmac-content-digest", "sha-256=:AAAA:")
+	if err := Verify(context.Background(), md, message, getSecret, WithContentDigest()); !errors.Is(err, ErrInvalidContentDigest) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidContentDigest)
+	}
+}
+
+// largeRequest returns a request of about 1 MB.
+func largeRequest() proto.Message {
+	return wrapperspb.String(strings.Repeat("go-grpc-hmac ", 1<<20/len("go-grpc-hmac ")))
+}
+
+func BenchmarkNewMessage_1MB(b *testing.B) {
+	req := largeRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewMessage(req, "/svc/Method"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkContentDigest_1MB(b *testing.B) {
+	req := largeRequest()
+	o := newOptions(WithContentDigest())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := o.newMessage(req, "/svc/Method"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package hmac
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// percentBase is the number of buckets of EnforcePercent.
+const percentBase = 100
+
+// AuditFunc reports a request that failed authentication or authorization but was let through by WithDryRun, err is
+// the error the request would have been rejected with. It can log the failure or count it in metrics.
+type AuditFunc func(ctx context.Context, method string, err error)
+
+// Enforce reports whether failed requests to method are rejected while WithDryRun is used.
+type Enforce func(ctx context.Context, method string) bool
+
+// EnforceMethods enforces the given full methods, e.g. "/example.UserService/GetUser".
+func EnforceMethods(methods ...string) Enforce {
+	return func(_ context.Context, method string) bool {
+		return slices.Contains(methods, method)
+	}
+}
+
+// EnforcePercent enforces requests of percent out of 100 key ids, picked by a hash of x-hmac-key-id, so a client is
+// either always or never enforced. Requests without x-hmac-key-id are in the same bucket.
+func EnforcePercent(percent int) Enforce {
+	return func(ctx context.Context, _ string) bool {
+		h := fnv.New32a()
+		if keyID := metadata.ValueFromIncomingContext(ctx, "x-hmac-key-id"); len(keyID) > 0 {
+			_, _ = h.Write([]byte(keyID[0]))
+		}
+		return int(h.Sum32()%percentBase) < percent
+	}
+}
+
+// dryRun holds the AuditFunc and Enforce of WithDryRun.
+type dryRun struct {
+	audit   AuditFunc
+	enforce Enforce
+}
+
+// allows reports whether a request to method failing with err is let through, reporting it to the AuditFunc.
+// Requests are never let through without WithDryRun.
+func (d *dryRun) allows(ctx context.Context, method string, err error) bool {
+	if d == nil || d.enforce != nil && d.enforce(ctx, method) {
+		return false
+	}
+	logger.Printf("dry run: allowing request to method %s: %q", method, err)
+	if d.audit != nil {
+		d.audit(ctx, method, err)
+	}
+	return true
+}
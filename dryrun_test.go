@@ -0,0 +1,136 @@
+package hmac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// audited records the requests reported to an AuditFunc.
+type audited []string
+
+func (a *audited) audit(_ context.Context, method string, err error) {
+	*a = append(*a, method+": "+err.Error())
+}
+
+func TestWithDryRun(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	sign := func(method string) metadata.MD {
+		message, _ := NewMessage(nil, method)
+		return Sign("key1", "secret1", message)
+	}
+	tests := []struct {
+		name     string
+		enforce  Enforce
+		method   string
+		md       metadata.MD
+		want     Identity
+		wantErr  bool
+		wantAuds int
+	}{
+		{name: "Valid", method: "/svc/Method", md: sign("/svc/Method"), want: Identity{KeyID: "key1"}},
+		{name: "Unsigned", method: "/svc/Method", md: metadata.MD{}, wantAuds: 1},
+		{name: "InvalidSignature", method: "/svc/Method", md: sign("/svc/Other"), wantAuds: 1},
+		{name: "EnforcedMethod", enforce: EnforceMethods("/svc/Method"), method: "/svc/Method", md: metadata.MD{}, wantErr: true},
+		{name: "OtherMethod", enforce: EnforceMethods("/svc/Other"), method: "/svc/Method", md: metadata.MD{}, wantAuds: 1},
+		{name: "InsufficientScope", method: "/svc/Scoped", md: sign("/svc/Scoped"), want: Identity{KeyID: "key1"}, wantAuds: 1},
+		{name: "EnforcedScope", enforce: EnforcePercent(100), method: "/svc/Scoped", md: sign("/svc/Scoped"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var audits audited
+			s := NewServerInterceptor(getSecret, WithDryRun(audits.audit, tt.enforce)).(*serverInterceptor) //nolint:forcetypeassert
+			s.scopes = map[string][]string{"/svc/Scoped": {"admin"}}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			var got Identity
+			unary := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				got, _ = IdentityFromContext(ctx)
+				return nil, nil //nolint:nilnil
+			}
+			if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, unary); (err != nil) != tt.wantErr {
+				t.Fatalf("UnaryServerInterceptor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UnaryServerInterceptor() identity = %v, want %v", got, tt.want)
+			}
+			stream := func(interface{}, grpc.ServerStream) error { return nil }
+			if err := s.StreamServerInterceptor(nil, &incomingServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, stream); (err != nil) != tt.wantErr {
+				t.Fatalf("StreamServerInterceptor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(audits) != 2*tt.wantAuds {
+				t.Errorf("audited = %v, want %d per interceptor", audits, tt.wantAuds)
+			}
+		})
+	}
+}
+
+func TestWithDryRun_nilAudit(t *testing.T) {
+	s := NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil }, WithDryRun(nil, nil))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+	called := false
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil //nolint:nilnil
+	}
+	if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, handler); err != nil || !called {
+		t.Errorf("UnaryServerInterceptor() error = %v, handler called %v", err, called)
+	}
+}
+
+// transportStream is a grpc.ServerTransportStream of method.
+type transportStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s transportStream) Method() string {
+	return s.method
+}
+
+func TestAuthFunc_dryRun(t *testing.T) {
+	var audits audited
+	authFunc := AuthFunc(func(context.Context, string) (string, error) { return "secret1", nil }, WithDryRun(audits.audit, nil))
+	ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), metadata.MD{}), transportStream{method: "/svc/Method"})
+	got, err := authFunc(ctx)
+	if err != nil || got != ctx {
+		t.Errorf("AuthFunc() got = %v, %v, want the request context", got, err)
+	}
+	if len(audits) != 1 || !strings.HasPrefix(audits[0], "/svc/Method: ") {
+		t.Errorf("audited = %v, want /svc/Method", audits)
+	}
+}
+
+func TestEnforcePercent(t *testing.T) {
+	count := func(percent int) int {
+		enforce := EnforcePercent(percent)
+		n := 0
+		for i := 0; i < 1000; i++ {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-hmac-key-id", fmt.Sprintf("key%d", i)))
+			if enforce(ctx, "/svc/Method") {
+				n++
+			}
+		}
+		return n
+	}
+	if got := count(0); got != 0 {
+		t.Errorf("EnforcePercent(0) enforced %d of 1000", got)
+	}
+	if got := count(100); got != 1000 {
+		t.Errorf("EnforcePercent(100) enforced %d of 1000", got)
+	}
+	if got := count(30); got < 250 || got > 350 {
+		t.Errorf("EnforcePercent(30) enforced %d of 1000", got)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-hmac-key-id", "key1"))
+	enforce := EnforcePercent(50)
+	first := enforce(ctx, "/svc/Method")
+	for i := 0; i < 10; i++ {
+		if enforce(ctx, "/svc/Other") != first {
+			t.Fatal("EnforcePercent() is not stable for a key id")
+		}
+	}
+}
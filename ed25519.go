@@ -0,0 +1,77 @@
+package hmac
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// GetPublicKey is a function that returns the Ed25519 public key for a given keyId.
+// Returns nil in case the keyId is not found instead of an error.
+// If the function returns an error, the request is rejected.
+type GetPublicKey func(ctx context.Context, keyId string) (publicKey ed25519.PublicKey, err error)
+
+// ed25519PrivateKey signs messages with Ed25519.
+type ed25519PrivateKey ed25519.PrivateKey
+
+// signature returns the base64 encoded Ed25519 signature of message.
+func (k ed25519PrivateKey) signature(message canonical) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(k), []byte(message.String())))
+}
+
+// ed25519PublicKey verifies Ed25519 signatures.
+type ed25519PublicKey ed25519.PublicKey
+
+func (k ed25519PublicKey) verify(message canonical, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize || len(k) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(k), []byte(message.String()), sig)
+}
+
+// verifiers adapts getPublicKey to return the public key as verifier.
+func (getPublicKey GetPublicKey) verifiers() getVerifier {
+	return func(ctx context.Context, keyId string) (verifier, error) {
+		publicKey, err := getPublicKey(ctx, keyId)
+		if publicKey == nil {
+			return nil, err
+		}
+		return ed25519PublicKey(publicKey), err
+	}
+}
+
+// NewEd25519ClientInterceptor returns a new client interceptor that signs outgoing requests with an Ed25519 private key
+// instead of a HMAC secret. The metadata is the same as NewClientInterceptor, x-hmac-signature holds the base64 encoded
+// Ed25519 signature. WithDerivedKeys does not apply to Ed25519 signatures.
+func NewEd25519ClientInterceptor(keyId string, privateKey ed25519.PrivateKey, opts ...Option) ClientInterceptor {
+	return &clientInterceptor{keyId, ed25519PrivateKey(privateKey), ed25519Options(opts...)}
+}
+
+// NewEd25519ServerInterceptor returns a new server interceptor that verifies requests signed by
+// NewEd25519ClientInterceptor with the public key returned by GetPublicKey.
+func NewEd25519ServerInterceptor(getPublicKey GetPublicKey, opts ...Option) ServerInterceptor {
+	o := ed25519Options(opts...)
+	return newServerInterceptor(authForKeys(getPublicKey.verifiers(), o), o)
+}
+
+// SignEd25519 is like Sign using an Ed25519 private key.
+func SignEd25519(keyID string, privateKey ed25519.PrivateKey, message string, opts ...Option) metadata.MD {
+	o := ed25519Options(opts...)
+	return o.sign(context.Background(), keyID, ed25519PrivateKey(privateKey), message, call{authority: o.signedAuthority()})
+}
+
+// VerifyEd25519 is like Verify using the Ed25519 public key returned by getPublicKey.
+func VerifyEd25519(ctx context.Context, md metadata.MD, message string, getPublicKey GetPublicKey, opts ...Option) error {
+	o := ed25519Options(opts...)
+	return o.verify(ctx, md, messageOf(message), getPublicKey.verifiers())
+}
+
+// ed25519Options returns the options without derived keys, which only apply to HMAC secrets.
+func ed25519Options(opts ...Option) options {
+	o := newOptions(opts...)
+	o.derived = nil
+	return o
+}
@@ -0,0 +1,127 @@
+package hmac
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func generateKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return publicKey, privateKey
+}
+
+func TestSignVerifyEd25519(t *testing.T) {
+	publicKey, privateKey := generateKey(t)
+	otherKey, _ := generateKey(t)
+	getPublicKey := func(_ context.Context, keyID string) (ed25519.PublicKey, error) {
+		switch keyID {
+		case "key-id":
+			return publicKey, nil
+		case "other-key-id":
+			return otherKey, nil
+		case "error":
+			return nil, errors.New("unavailable")
+		default:
+			return nil, nil
+		}
+	}
+	clock := ClockFunc(func() time.Time { return time.Unix(1688212800, 0) })
+	opts := []Option{WithClock(clock), WithTimestamp(time.Minute), WithDerivedKeys()}
+	md := SignEd25519("key-id", privateKey, "plain-text", opts...)
+	if got := md.Get("x-hmac-date"); len(got) != 0 {
+		t.Errorf("SignEd25519() got x-hmac-date %v, derived keys do not apply", got)
+	}
+	with := func(key, value string) metadata.MD {
+		copied := md.Copy()
+		copied.Set(key, value)
+		return copied
+	}
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		message string
+		want    error
+	}{
+		{"Valid", md, "plain-text", nil},
+		{"OtherMessage", md, "other-text", ErrInvalidHmacSignature},
+		{"OtherKey", with("x-hmac-key-id", "other-key-id"), "plain-text", ErrInvalidHmacSignature},
+		{"UnknownKey", with("x-hmac-key-id", "unknown"), "plain-text", ErrInvalidHmacKeyID},
+		{"NotBase64", with("x-hmac-signature", "not base64"), "plain-text", ErrInvalidHmacSignature},
+		{"ShortSignature", with("x-hmac-signature", "c2lnbmF0dXJl"), "plain-text", ErrInvalidHmacSignature},
+		{"MissingTimestamp", metadata.Pairs("x-hmac-key-id", "key-id", "x-hmac-signature", md.Get("x-hmac-signature")[0]), "plain-text", ErrMissingHmacTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyEd25519(context.Background(), tt.md, tt.message, getPublicKey, opts...); !errors.Is(got, tt.want) {
+				t.Errorf("VerifyEd25519() error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if err := VerifyEd25519(context.Background(), with("x-hmac-key-id", "error"), "plain-text", getPublicKey, opts...); err == nil {
+		t.Error("VerifyEd25519() expected error when GetPublicKey fails")
+	}
+	if err := Verify(context.Background(), md, "plain-text", func(context.Context, string) (string, error) { return "secret", nil }, opts[:2]...); !errors.Is(err, ErrInvalidHmacSignature) {
+		t.Errorf("Verify() of Ed25519 signature error = %v, want %v", err, ErrInvalidHmacSignature)
+	}
+}
+
+func TestEd25519Interceptors(t *testing.T) {
+	publicKey, privateKey := generateKey(t)
+	getPublicKey := func(_ context.Context, keyID string) (ed25519.PublicKey, error) {
+		if keyID == "key1" {
+			return publicKey, nil
+		}
+		return nil, nil
+	}
+	client := NewEd25519ClientInterceptor("key1", privateKey)
+	server := NewEd25519ServerInterceptor(getPublicKey)
+	req := &struct{ Field string }{Field: "value"}
+	invoker := func(ctx context.Context, method string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, err := server.UnaryServerInterceptor(metadata.NewIncomingContext(ctx, md), req, info, handler)
+		return err
+	}
+	if err := client.UnaryClientInterceptor(context.Background(), "/svc/Method", req, nil, nil, invoker); err != nil {
+		t.Errorf("UnaryServerInterceptor() error = %v", err)
+	}
+	_, otherKey := generateKey(t)
+	other := NewEd25519ClientInterceptor("key1", otherKey)
+	if err := other.UnaryClientInterceptor(context.Background(), "/svc/Method", req, nil, nil, invoker); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("UnaryServerInterceptor() with other key error = %v, want %v", err, ErrUnauthorized)
+	}
+	streamer := func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		handler := func(interface{}, grpc.ServerStream) error { return nil }
+		info := &grpc.StreamServerInfo{FullMethod: method}
+		return nil, server.StreamServerInterceptor(nil, &incomingServerStream{ctx: metadata.NewIncomingContext(ctx, md)}, info, handler)
+	}
+	if _, err := client.StreamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, "/svc/Stream", streamer); err != nil {
+		t.Errorf("StreamServerInterceptor() error = %v", err)
+	}
+	server.IgnoredMethods("/svc/Stream")
+	if _, err := other.StreamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, "/svc/Stream", streamer); err != nil {
+		t.Errorf("StreamServerInterceptor() of ignored method error = %v", err)
+	}
+}
+
+type incomingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s *incomingServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -65,18 +65,10 @@ func (s *server) start() {
 	}
 }
 
-func (s *server) getSecrets(_ context.Context, keyId string) (string, error) {
-	secrets := map[string]string{
-		os.Getenv("key_id"): os.Getenv("secret_key"),
-	}
-	if secret, ok := secrets[keyId]; ok {
-		return secret, nil
-	}
-	return "", fmt.Errorf("key not found")
-}
-
 func (s *server) setup() {
-	interceptor := hmac.NewServerInterceptor(s.getSecrets)
+	interceptor := hmac.NewServerInterceptor(hmac.StaticSecrets(map[string]string{
+		os.Getenv("key_id"): os.Getenv("secret_key"),
+	}))
 	opts := []grpc.ServerOption{
 		interceptor.UnaryInterceptor(),
 		interceptor.StreamInterceptor(),
@@ -0,0 +1,122 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator authenticates requests by other means than HMAC, e.g. a bearer token in the metadata, and returns the
+// subject of the request. If the request is not authenticated an error is returned.
+type Authenticator func(ctx context.Context) (subject string, err error)
+
+// Combine is how WithFallback combines HMAC with an Authenticator.
+type Combine int
+
+const (
+	// AnyOf authenticates requests with x-hmac metadata using HMAC and requests without using the Authenticator.
+	AnyOf Combine = iota
+	// AllOf requires requests to be authenticated by both HMAC and the Authenticator.
+	AllOf
+)
+
+// fallback holds the Authenticator of WithFallback.
+type fallback struct {
+	authenticate Authenticator
+	combine      Combine
+}
+
+// Identity of a request authenticated by the server interceptors or AuthFunc, see IdentityFromContext.
+type Identity struct {
+	// KeyID authenticated with HMAC or forwarded by a trusted HTTP gateway, empty if HMAC was not used.
+	KeyID string
+	// Subject returned by the Authenticator of WithFallback, empty if it was not used.
+	Subject string
+}
+
+// Name returns the KeyID, or the Subject if the request was not authenticated with HMAC.
+func (i Identity) Name() string {
+	if i.KeyID != "" {
+		return i.KeyID
+	}
+	return i.Subject
+}
+
+// identityKey is the context key of the Identity of a request.
+type identityKey struct{}
+
+// IdentityFromContext returns the Identity of a request authenticated by the server interceptors or AuthFunc.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// authenticate verifies the request with HMAC and the fallback Authenticator, if any, and returns the context with the
+// Identity of the request.
+func (s *serverInterceptor) authenticate(ctx context.Context, message string) (context.Context, error) {
+	var identity Identity
+	useHmac := s.fallback == nil || s.fallback.combine == AllOf || hasHmac(ctx)
+	if useHmac {
+		if err := s.auth(ctx, message); err != nil {
+			return nil, err
+		}
+		identity.KeyID = keyIDOf(ctx, s.forwarded)
+	}
+	if s.fallback != nil && (s.fallback.combine == AllOf || !useHmac) {
+		subject, err := s.fallback.authenticate(ctx)
+		if err != nil {
+			return nil, &fallbackError{err}
+		}
+		identity.Subject = subject
+	}
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+// fallbackError is an error of the fallback Authenticator.
+type fallbackError struct {
+	err error
+}
+
+func (e *fallbackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fallbackError) Unwrap() error {
+	return e.err
+}
+
+// unauthorized returns the error rejecting a request failing authentication with err, the error of the fallback
+// Authenticator if it has a gRPC status, otherwise ErrUnauthorized so HMAC failures are not disclosed to clients.
+func unauthorized(err error) error {
+	var fallbackErr *fallbackError
+	var statusErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &fallbackErr) && errors.As(fallbackErr.err, &statusErr) {
+		return statusErr.GRPCStatus().Err()
+	}
+	return ErrUnauthorized
+}
+
+// hasHmac reports whether the incoming metadata of ctx contains any x-hmac metadata.
+func hasHmac(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k := range md {
+		if strings.HasPrefix(k, "x-hmac-") {
+			return true
+		}
+	}
+	return false
+}
+
+// identityServerStream is a grpc.ServerStream with the context holding the Identity of the request.
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
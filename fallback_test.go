@@ -0,0 +1,134 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// bearer authenticates requests with the authorization metadata "Bearer alice".
+func bearer(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if getFirst(md, "authorization") != "Bearer alice" {
+		return "", errors.New("invalid bearer token")
+	}
+	return "alice", nil
+}
+
+func TestWithFallback(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	message, _ := NewMessage(nil, "/svc/Method")
+	signed := Sign("key1", "secret1", message)
+	token := metadata.Pairs("authorization", "Bearer alice")
+	tests := []struct {
+		name    string
+		opts    []Option
+		md      metadata.MD
+		want    Identity
+		wantErr bool
+	}{
+		{name: "WithoutFallback", md: signed, want: Identity{KeyID: "key1"}},
+		{name: "WithoutFallbackUnsigned", md: token, wantErr: true},
+		{name: "AnyOfHmac", opts: []Option{WithFallback(bearer, AnyOf)}, md: signed, want: Identity{KeyID: "key1"}},
+		{name: "AnyOfBearer", opts: []Option{WithFallback(bearer, AnyOf)}, md: token, want: Identity{Subject: "alice"}},
+		{name: "AnyOfNone", opts: []Option{WithFallback(bearer, AnyOf)}, md: metadata.MD{}, wantErr: true},
+		{name: "AnyOfInvalidHmac", opts: []Option{WithFallback(bearer, AnyOf)}, md: metadata.Join(Sign("key1", "secret2", message), token), wantErr: true},
+		{name: "AllOfBoth", opts: []Option{WithFallback(bearer, AllOf)}, md: metadata.Join(signed, token), want: Identity{KeyID: "key1", Subject: "alice"}},
+		{name: "AllOfHmac", opts: []Option{WithFallback(bearer, AllOf)}, md: signed, wantErr: true},
+		{name: "AllOfBearer", opts: []Option{WithFallback(bearer, AllOf)}, md: token, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServerInterceptor(getSecret, tt.opts...)
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			var got Identity
+			unary := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				got, _ = IdentityFromContext(ctx)
+				return nil, nil //nolint:nilnil
+			}
+			if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, unary); (err != nil) != tt.wantErr {
+				t.Fatalf("UnaryServerInterceptor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UnaryServerInterceptor() identity = %v, want %v", got, tt.want)
+			}
+			got = Identity{}
+			stream := func(_ interface{}, ss grpc.ServerStream) error {
+				got, _ = IdentityFromContext(ss.Context())
+				return nil
+			}
+			if err := s.StreamServerInterceptor(nil, &incomingServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/svc/Method"}, stream); (err != nil) != tt.wantErr {
+				t.Fatalf("StreamServerInterceptor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StreamServerInterceptor() identity = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithFallback_errors(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	revoked := status.Error(codes.PermissionDenied, "token revoked")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Status", err: revoked, want: revoked},
+		{name: "WrappedStatus", err: fmt.Errorf("bearer: %w", revoked), want: revoked},
+		{name: "Other", err: errors.New("invalid bearer token"), want: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := func(context.Context) (string, error) { return "", tt.err }
+			s := NewServerInterceptor(getSecret, WithFallback(authenticator, AnyOf))
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{})
+			handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil } //nolint:nilnil
+			_, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, handler)
+			if status.Code(err) != status.Code(tt.want) || status.Convert(err).Message() != status.Convert(tt.want).Message() {
+				t.Errorf("UnaryServerInterceptor() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	// HMAC failures are not disclosed with AllOf either
+	s := NewServerInterceptor(getSecret, WithFallback(bearer, AllOf))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer alice"))
+	if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("UnaryServerInterceptor() error = %v, want %v", err, ErrUnauthorized)
+	}
+}
+
+func TestIdentity_Name(t *testing.T) {
+	if got := (Identity{KeyID: "key1", Subject: "alice"}).Name(); got != "key1" {
+		t.Errorf("Name() = %v, want key1", got)
+	}
+	if got := (Identity{Subject: "alice"}).Name(); got != "alice" {
+		t.Errorf("Name() = %v, want alice", got)
+	}
+}
+
+func TestIdentityFromContext_forwarded(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	s := NewServerInterceptor(getSecret, WithForwardedKeyID("gateway", time.Minute))
+	md := ForwardKeyID("key1", "/svc/Method", NewSecret([]byte("gateway")))
+	var got Identity
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		got, _ = IdentityFromContext(ctx)
+		return nil, nil //nolint:nilnil
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if _, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, handler); err != nil {
+		t.Fatalf("UnaryServerInterceptor() error = %v", err)
+	}
+	if got != (Identity{KeyID: "key1"}) {
+		t.Errorf("UnaryServerInterceptor() identity = %v, want forwarded key id", got)
+	}
+}
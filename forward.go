@@ -0,0 +1,55 @@
+package hmac
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// forwarding holds the secret and maximum age of key ids forwarded by a HTTP gateway.
+type forwarding struct {
+	secret *Secret
+	maxAge time.Duration
+}
+
+// ForwardKeyID returns the metadata forwarding the key id of a request to method authenticated by a HTTP gateway, e.g.
+// the middleware of the httphmac package, to the server interceptor. The metadata is signed with a secret shared by the
+// gateway and the servers configured with WithForwardedKeyID, the timestamp is the time of the Clock of opts.
+func ForwardKeyID(keyID, method string, secret *Secret, opts ...Option) metadata.MD {
+	o := newOptions(opts...)
+	timestamp := strconv.FormatInt(o.now().Unix(), 10)
+	return metadata.Pairs(
+		"x-hmac-forwarded-key-id", keyID,
+		"x-hmac-forwarded-timestamp", timestamp,
+		"x-hmac-forwarded-signature", secret.signature(messageOf(forwardedMessage(keyID, timestamp, method))),
+	)
+}
+
+// forwardedMessage returns the message signed by ForwardKeyID.
+func forwardedMessage(keyID, timestamp, method string) string {
+	return "forwarded-key-id=" + keyID + ";timestamp=" + timestamp + ";method=" + method
+}
+
+// verifyForwarded checks the key id forwarded by a HTTP gateway for a request to method, reporting whether the request
+// was forwarded. Requests with a valid forwarded key id are not verified again.
+func (o *options) verifyForwarded(md metadata.MD, method string) (bool, error) {
+	keyID := getFirst(md, "x-hmac-forwarded-key-id")
+	if o.forwarding == nil || keyID == "" {
+		return false, nil
+	}
+	raw := getFirst(md, "x-hmac-forwarded-timestamp")
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return true, ErrInvalidForwardedKeyID
+	}
+	if age := o.now().Sub(time.Unix(unix, 0)); age > o.forwarding.maxAge || age < -o.forwarding.maxAge {
+		logger.Printf("forwarded key id %s timestamp %s older than %s", keyID, raw, o.forwarding.maxAge)
+		return true, ErrInvalidForwardedKeyID
+	}
+	if !o.forwarding.secret.verify(messageOf(forwardedMessage(keyID, raw, method)), getFirst(md, "x-hmac-forwarded-signature")) {
+		logger.Printf("invalid signature of forwarded key id %s", keyID)
+		return true, ErrInvalidForwardedKeyID
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithForwardedKeyID(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	auth := authForSecrets(getSecret, WithForwardedKeyID("gateway", time.Minute), WithClock(ClockFunc(func() time.Time { return now })))
+	gateway := NewSecret([]byte("gateway"))
+	at := func(when time.Time) Option { return WithClock(ClockFunc(func() time.Time { return when })) }
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want error
+	}{
+		{name: "Forwarded", md: ForwardKeyID("key1", "/svc/Method", gateway, at(now))},
+		{name: "ForwardedWithinMaxAge", md: ForwardKeyID("key1", "/svc/Method", gateway, at(now.Add(-time.Minute)))},
+		{name: "ForwardedTooOld", md: ForwardKeyID("key1", "/svc/Method", gateway, at(now.Add(-2*time.Minute))), want: ErrInvalidForwardedKeyID},
+		{name: "ForwardedOtherSecret", md: ForwardKeyID("key1", "/svc/Method", NewSecret([]byte("other")), at(now)), want: ErrInvalidForwardedKeyID},
+		{name: "ForwardedOtherMethod", md: ForwardKeyID("key1", "/svc/Other", gateway, at(now)), want: ErrInvalidForwardedKeyID},
+		{name: "ForwardedOtherKeyID", md: func() metadata.MD {
+			md := ForwardKeyID("key1", "/svc/Method", gateway, at(now))
+			md.Set("x-hmac-forwarded-key-id", "admin")
+			return md
+		}(), want: ErrInvalidForwardedKeyID},
+		{name: "ForwardedInvalidTimestamp", md: func() metadata.MD {
+			md := ForwardKeyID("key1", "/svc/Method", gateway, at(now))
+			md.Set("x-hmac-forwarded-timestamp", "now")
+			return md
+		}(), want: ErrInvalidForwardedKeyID},
+		{name: "Signed", md: Sign("key1", "secret1", "method=/svc/Method")},
+		{name: "NotSigned", md: metadata.MD{}, want: ErrMissingHmac},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			if err := auth(ctx, "method=/svc/Method"); !errors.Is(err, tt.want) {
+				t.Errorf("auth() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardKeyID_notConfigured(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	md := ForwardKeyID("key1", "/svc/Method", NewSecret([]byte("gateway")))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if err := authForSecrets(getSecret)(ctx, "method=/svc/Method"); !errors.Is(err, ErrMissingHmac) {
+		t.Errorf("auth() error = %v, want %v", err, ErrMissingHmac)
+	}
+}
+
+func TestWithForwardedKeyID_emptySecret(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	md := ForwardKeyID("admin", "/svc/Method", NewSecret(nil))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if err := authForSecrets(getSecret, WithForwardedKeyID("", time.Minute))(ctx, "method=/svc/Method"); !errors.Is(err, ErrMissingHmac) {
+		t.Errorf("auth() error = %v, want %v", err, ErrMissingHmac)
+	}
+}
@@ -4,12 +4,20 @@ go 1.23.0
 
 toolchain go1.24.1
 
-require google.golang.org/grpc v1.75.1
+require (
+	connectrpc.com/connect v1.18.1
+	github.com/bufbuild/protocompile v0.14.1
+	github.com/grpc-ecosystem/go-grpc-middleware/v2 v2.3.2
+	golang.org/x/crypto v0.39.0
+	golang.org/x/net v0.41.0
+	google.golang.org/grpc v1.75.1
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
-	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sync v0.15.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.26.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
-	google.golang.org/protobuf v1.36.6 // indirect
 )
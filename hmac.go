@@ -3,15 +3,15 @@ package hmac
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -21,12 +21,25 @@ import (
 const emptyBracketLength = 2
 
 var (
-	logger                  = log.New(io.Discard, "[go-grpc-hmac] ", log.LstdFlags|log.LUTC)
-	ErrInvalidHmacKeyID     = status.Errorf(codes.Unauthenticated, "invalid x-hmac-key-id")
-	ErrInvalidHmacSignature = status.Errorf(codes.Unauthenticated, "invalid x-hmac-signature")
-	ErrMissingHmac          = status.Errorf(codes.Unauthenticated, "missing x-hmac-signature metadata")
-	ErrMissingHmacKeyID     = status.Errorf(codes.Unauthenticated, "missing x-hmac-key-id metadata")
-	ErrMissingMetadata      = status.Errorf(codes.Unauthenticated, "missing hmac metadata")
+	logger                   = log.New(io.Discard, "[go-grpc-hmac] ", log.LstdFlags|log.LUTC)
+	ErrInvalidHmacKeyID      = status.Errorf(codes.Unauthenticated, "invalid x-hmac-key-id")
+	ErrInvalidHmacSignature  = status.Errorf(codes.Unauthenticated, "invalid x-hmac-signature")
+	ErrMissingHmac           = status.Errorf(codes.Unauthenticated, "missing x-hmac-signature metadata")
+	ErrMissingHmacKeyID      = status.Errorf(codes.Unauthenticated, "missing x-hmac-key-id metadata")
+	ErrMissingMetadata       = status.Errorf(codes.Unauthenticated, "missing hmac metadata")
+	ErrInvalidHmacTimestamp  = status.Errorf(codes.Unauthenticated, "invalid x-hmac-timestamp")
+	ErrMissingHmacTimestamp  = status.Errorf(codes.Unauthenticated, "missing x-hmac-timestamp metadata")
+	ErrExpiredHmac           = status.Errorf(codes.Unauthenticated, "expired x-hmac-expires")
+	ErrInvalidHmacExpires    = status.Errorf(codes.Unauthenticated, "invalid x-hmac-expires")
+	ErrMissingHmacExpires    = status.Errorf(codes.Unauthenticated, "missing x-hmac-expires metadata")
+	ErrInvalidAuthority      = status.Errorf(codes.Unauthenticated, "invalid :authority")
+	ErrMissingAuthority      = status.Errorf(codes.Unauthenticated, "missing :authority metadata")
+	ErrInvalidChannelBinding = status.Errorf(codes.Unauthenticated, "invalid TLS channel binding")
+	ErrMissingChannelBinding = status.Errorf(codes.Unauthenticated, "missing TLS channel binding")
+	ErrInvalidContentDigest  = status.Errorf(codes.Unauthenticated, "invalid x-hmac-content-digest")
+	ErrInvalidHmacDate       = status.Errorf(codes.Unauthenticated, "invalid x-hmac-date")
+	ErrMissingHmacDate       = status.Errorf(codes.Unauthenticated, "missing x-hmac-date metadata")
+	ErrInvalidForwardedKeyID = status.Errorf(codes.Unauthenticated, "invalid x-hmac-forwarded-key-id")
 )
 
 func init() {
@@ -46,41 +59,78 @@ func DisableLogging() {
 	logger.SetOutput(io.Discard)
 }
 
+// logging reports whether logging is enabled, hot paths check it to avoid formatting log messages.
+func logging() bool {
+	return logger.Writer() != io.Discard
+}
+
+// requestEncoder is a JSON encoder with its buffer, reused between messages.
+type requestEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// maxPooledMessage is the buffer capacity above which a requestEncoder is not returned to the pool.
+const maxPooledMessage = 64 << 10
+
+var requestEncoders = sync.Pool{New: func() interface{} {
+	e := &requestEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}}
+
+func (e *requestEncoder) release() {
+	if e.buf.Cap() <= maxPooledMessage {
+		e.buf.Reset()
+		requestEncoders.Put(e)
+	}
+}
+
 // NewMessage returns a string representation of the request and method.
 func NewMessage(req interface{}, method string) (string, error) {
-	buf := new(bytes.Buffer)
 	if req == nil {
 		logger.Println("warning: no request, using only method name as message")
 		return "method=" + method, nil
 	}
-	reqBuf := new(bytes.Buffer)
-	if err := json.NewEncoder(reqBuf).Encode(req); err != nil {
-		if strings.Contains(err.Error(), "has no exported fields") {
-			logger.Println("warning: no exported fields in request, using only method name as message")
-			goto ADDMETHOD
-		}
-		return "", fmt.Errorf("failed to encode request: %w", err)
-	}
-	reqBuf.Truncate(reqBuf.Len() - 1) // remove trailing newline
-	if reqBuf.Len() > emptyBracketLength {
-		buf.WriteString("request=")
-		buf.Write(reqBuf.Bytes())
-		buf.WriteString(";")
+	e := requestEncoders.Get().(*requestEncoder) //nolint:forcetypeassert
+	defer e.release()
+	if err := e.encode(req); err != nil {
+		return "", err
 	}
-ADDMETHOD:
-	buf.WriteString("method=" + method)
+	var buf strings.Builder
+	buf.Grow(e.buf.Len() + len(method))
+	buf.Write(e.buf.Bytes())
+	buf.WriteString(method)
 	return buf.String(), nil
 }
 
+// encode writes the message of req up to the method to the buffer, "request=<json>;method=" or "method=" if the
+// request is empty or has no exported fields.
+func (e *requestEncoder) encode(req interface{}) error {
+	e.buf.WriteString("request=")
+	err := e.enc.Encode(req)
+	switch {
+	case err != nil && strings.Contains(err.Error(), "has no exported fields"):
+		logger.Println("warning: no exported fields in request, using only method name as message")
+		e.buf.Reset()
+	case err != nil:
+		return fmt.Errorf("failed to encode request: %w", err)
+	case e.buf.Len()-len("request=\n") > emptyBracketLength:
+		e.buf.Truncate(e.buf.Len() - 1) // replace trailing newline by the separator
+		e.buf.WriteString(";")
+	default:
+		e.buf.Reset()
+	}
+	e.buf.WriteString("method=")
+	return nil
+}
+
 // Bytes generate a HMAC signature and return it as a base64 encoded []byte.
 func Bytes(secretKey string, message string) []byte {
 	logger.Printf("generating signature for message %q", message)
-	mac := hmac.New(sha512.New512_256, []byte(secretKey))
-	mac.Write([]byte(message))
-	in := mac.Sum(nil)
-	data := make([]byte, base64.StdEncoding.EncodedLen(len(in)))
-	base64.StdEncoding.Encode(data, in)
-	return data
+	key := newStringSecret(secretKey)
+	defer key.Wipe()
+	return key.Sign(message)
 }
 
 // String generates a HMAC signature and returns it as a base64 encoded string.
@@ -88,34 +138,394 @@ func String(secretKey string, message string) string {
 	return string(Bytes(secretKey, message))
 }
 
-func authForSecrets(getSecret GetSecret) func(ctx context.Context, message string) error {
+// Sign returns the metadata authenticating message with keyID and secret, as added by the client interceptor.
+// WithChannelBinding requires a connection and cannot be used with Sign.
+func Sign(keyID, secret, message string, opts ...Option) metadata.MD {
+	key := newStringSecret(secret)
+	defer key.Wipe()
+	return SignSecret(keyID, key, message, opts...)
+}
+
+// SignSecret is like Sign using a Secret.
+func SignSecret(keyID string, secret *Secret, message string, opts ...Option) metadata.MD {
+	o := newOptions(opts...)
+	return o.sign(context.Background(), keyID, secret, message, call{authority: o.signedAuthority()})
+}
+
+// Verify checks md authenticates message using the secret returned by getSecret, as done by the server interceptor.
+// The returned error describes why verification failed.
+func Verify(ctx context.Context, md metadata.MD, message string, getSecret GetSecret, opts ...Option) error {
+	getSecretKey, wipe := wipedSecrets(getSecret)
+	defer wipe()
+	return VerifySecret(ctx, md, message, getSecretKey, opts...)
+}
+
+// wipedSecrets returns a GetSecretKey creating a Secret of each secret returned by getSecret, wiped by calling wipe.
+func wipedSecrets(getSecret GetSecret) (GetSecretKey, func()) {
+	var keys []*Secret
+	getSecretKey := func(ctx context.Context, keyId string) (*Secret, error) {
+		secret, err := getSecret(ctx, keyId)
+		if err != nil || secret == "" {
+			return nil, err
+		}
+		key := newStringSecret(secret)
+		keys = append(keys, key)
+		return key, nil
+	}
+	return getSecretKey, func() {
+		for _, key := range keys {
+			key.Wipe()
+		}
+	}
+}
+
+// VerifySecret is like Verify using a GetSecretKey.
+func VerifySecret(ctx context.Context, md metadata.MD, message string, getSecretKey GetSecretKey, opts ...Option) error {
+	o := newOptions(opts...)
+	return o.verify(ctx, md, messageOf(message), getSecretKey.verifiers())
+}
+
+// SignRequest returns the metadata authenticating req to method as added by the client interceptor, e.g. to sign
+// requests delivered out of band. Requests of streams are nil, only the method is signed.
+func SignRequest(keyID, secret string, req interface{}, method string, opts ...Option) (metadata.MD, error) {
+	o := newOptions(opts...)
+	message, digest, err := o.newMessage(req, method)
+	if err != nil {
+		return nil, err
+	}
+	key := newStringSecret(secret)
+	defer key.Wipe()
+	return o.sign(context.Background(), keyID, key, message, call{authority: o.signedAuthority(), contentDigest: digest}), nil
+}
+
+// VerifyRequest checks md authenticates req to method using the secret returned by getSecret, as done by the server
+// interceptor. Requests of streams are nil, only the method is verified. Unlike the server interceptor the scopes of
+// the (hmac.v1.auth) method option are not checked.
+func VerifyRequest(ctx context.Context, md metadata.MD, req interface{}, method string, getSecret GetSecret, opts ...Option) error {
+	o := newOptions(opts...)
+	message, _, err := o.newMessage(req, method)
+	if err != nil {
+		return err
+	}
+	getSecretKey, wipe := wipedSecrets(getSecret)
+	defer wipe()
+	return o.verify(ctx, md, messageOf(message), getSecretKey.verifiers())
+}
+
+func authForSecrets(getSecret GetSecret, opts ...Option) func(ctx context.Context, message string) error {
+	return authForSecretKeys(getSecret.Keyed(), opts...)
+}
+
+func authForSecretKeys(getSecretKey GetSecretKey, opts ...Option) func(ctx context.Context, message string) error {
+	return authForKeys(getSecretKey.verifiers(), newOptions(opts...))
+}
+
+func authForKeys(getKey getVerifier, o options) func(ctx context.Context, message string) error {
 	return func(ctx context.Context, message string) error {
 		md, ok := metadata.FromIncomingContext(ctx)
 		if !ok {
 			return ErrMissingMetadata
 		}
-		hmacSign := getFirst(md, "x-hmac-signature")
-		if hmacSign == "" {
-			return ErrMissingHmac
+		if forwarded, err := o.verifyForwarded(md, methodOf(message)); forwarded {
+			return err
 		}
-		hmacKeyID := getFirst(md, "x-hmac-key-id")
-		if hmacKeyID == "" {
-			return ErrMissingHmacKeyID
+		return o.verify(ctx, md, messageOf(message), getKey)
+	}
+}
+
+// signer signs messages returning the base64 encoded signature, implemented by Secret and Ed25519 private keys.
+type signer interface {
+	signature(message canonical) string
+}
+
+// verifier checks the base64 encoded signature of a message, implemented by Secret and Ed25519 public keys.
+type verifier interface {
+	verify(message canonical, signature string) bool
+}
+
+// getVerifier returns the verifier of a key id, or nil if the key id is unknown.
+type getVerifier func(ctx context.Context, keyId string) (verifier, error)
+
+// call holds the properties of a call signed along with the message.
+type call struct {
+	// authority is signed when WithAuthority is used.
+	authority string
+	// channelBinding is signed when WithChannelBinding is used.
+	channelBinding string
+	// contentDigest of the request sent when WithContentDigest is used.
+	contentDigest string
+}
+
+// sign returns the metadata authenticating message.
+func (o *options) sign(ctx context.Context, keyID string, secret signer, message string, c call) metadata.MD {
+	var pairs [maxSignedPairs]string
+	return metadata.Pairs(o.appendPairs(ctx, pairs[:0], keyID, secret, messageOf(message), c)...)
+}
+
+// maxSignedPairs is the number of key value pairs appended by appendPairs.
+const maxSignedPairs = 12
+
+// appendPairs appends the metadata authenticating message as key value pairs to md.
+func (o *options) appendPairs(ctx context.Context, md []string, keyID string, secret signer, message canonical, c call) []string {
+	md = append(md, "x-hmac-key-id", keyID)
+	if c.contentDigest != "" {
+		md = append(md, "x-hmac-content-digest", c.contentDigest)
+	}
+	if key, ok := secret.(*Secret); ok && o.derived != nil {
+		now := o.now()
+		date := now.UTC().Format(dateLayout)
+		// a wiped secret is kept, it signs with an empty signature
+		if derived, ok := o.derived.key(key, date, message.method(), now); ok {
+			secret = derived
 		}
-		secretKey, err := getSecret(ctx, hmacKeyID)
-		if err != nil {
-			log.Printf("internal error getting secret for keyID %s: %q", hmacKeyID, err)
-			return status.Error(codes.Internal, err.Error())
+		md = append(md, "x-hmac-date", date)
+	}
+	if o.maxSkew > 0 {
+		timestamp := strconv.FormatInt(o.now().Unix(), 10)
+		message.add("timestamp", timestamp)
+		md = append(md, "x-hmac-timestamp", timestamp)
+	}
+	if expires, ok := o.expiresAt(ctx); ok {
+		value := strconv.FormatInt(expires.Unix(), 10)
+		message.add("expires", value)
+		md = append(md, "x-hmac-expires", value)
+	}
+	if o.authority {
+		message.add("authority", c.authority)
+	}
+	if o.channelBinding {
+		message.add("channel-binding", c.channelBinding)
+	}
+	if logging() {
+		logger.Printf("generating signature for message %q", message.String())
+	}
+	return append(md, "x-hmac-signature", secret.signature(message))
+}
+
+func (o *options) verify(ctx context.Context, md metadata.MD, message canonical, getKey getVerifier) error {
+	hmacSign := getFirst(md, "x-hmac-signature")
+	if hmacSign == "" {
+		return ErrMissingHmac
+	}
+	hmacKeyID := getFirst(md, "x-hmac-key-id")
+	if hmacKeyID == "" {
+		return ErrMissingHmacKeyID
+	}
+	if err := o.verifyFields(ctx, md, &message); err != nil {
+		return err
+	}
+	date, err := o.verifyDate(md)
+	if err != nil {
+		return err
+	}
+	key, err := getKey(ctx, hmacKeyID)
+	if err != nil {
+		log.Printf("internal error getting secret for keyID %s: %q", hmacKeyID, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	if key == nil {
+		logger.Printf("no secret found for keyID %s", hmacKeyID)
+		return ErrInvalidHmacKeyID
+	}
+	if secretKey, ok := key.(*Secret); ok && o.derived != nil {
+		derived, ok := o.derived.key(secretKey, date, message.method(), o.now())
+		if !ok {
+			logger.Printf("secret for keyID %s is wiped", hmacKeyID)
+			return ErrInvalidHmacSignature
 		}
-		if secretKey == "" {
-			logger.Printf("no secret found for keyID %s", hmacKeyID)
-			return ErrInvalidHmacKeyID
+		key = derived
+	}
+	if logging() {
+		logger.Printf("verifying signature for message %q", message.String())
+	}
+	if !key.verify(message, hmacSign) {
+		return ErrInvalidHmacSignature
+	}
+	return nil
+}
+
+// verifyFields checks the optional fields of the signature and adds them to the message in the order of sign.
+func (o *options) verifyFields(ctx context.Context, md metadata.MD, message *canonical) error {
+	if err := o.verifyContentDigest(md, message); err != nil {
+		return err
+	}
+	if err := o.verifyTimestamp(md, message); err != nil {
+		return err
+	}
+	if err := o.verifyExpires(md, message); err != nil {
+		return err
+	}
+	if err := o.verifyAuthority(md, message); err != nil {
+		return err
+	}
+	return o.verifyChannelBinding(ctx, message)
+}
+
+// verifyTimestamp checks x-hmac-timestamp is within the allowed skew and adds it to the message.
+func (o *options) verifyTimestamp(md metadata.MD, message *canonical) error {
+	if o.maxSkew <= 0 {
+		return nil
+	}
+	raw := getFirst(md, "x-hmac-timestamp")
+	if raw == "" {
+		return ErrMissingHmacTimestamp
+	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return ErrInvalidHmacTimestamp
+	}
+	skew := o.now().Sub(time.Unix(unix, 0))
+	if skew > o.maxSkew || skew < -o.maxSkew {
+		logger.Printf("timestamp %s outside of allowed skew %s", raw, o.maxSkew)
+		return ErrInvalidHmacTimestamp
+	}
+	message.add("timestamp", raw)
+	return nil
+}
+
+// expiresAt returns the expiry time of a signature created now for a call with ctx.
+func (o *options) expiresAt(ctx context.Context) (time.Time, bool) {
+	if !o.expires {
+		return time.Time{}, false
+	}
+	deadline, ok := ctx.Deadline()
+	if o.expiry > 0 {
+		if expires := o.now().Add(o.expiry); !ok || expires.Before(deadline) {
+			return expires, true
 		}
-		if !hmac.Equal([]byte(hmacSign), Bytes(secretKey, message)) {
-			return ErrInvalidHmacSignature
+	}
+	return deadline, ok
+}
+
+// verifyExpires checks x-hmac-expires, if present, is not in the past and adds it to the message.
+func (o *options) verifyExpires(md metadata.MD, message *canonical) error {
+	raw := getFirst(md, "x-hmac-expires")
+	if raw == "" {
+		if o.expires && o.expiry > 0 {
+			return ErrMissingHmacExpires
 		}
 		return nil
 	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return ErrInvalidHmacExpires
+	}
+	now := o.now()
+	if now.Unix() > unix {
+		logger.Printf("signature expired at %s", raw)
+		return ErrExpiredHmac
+	}
+	if o.expires && o.expiry > 0 && time.Unix(unix, 0).Sub(now) > o.expiry+o.maxSkew {
+		logger.Printf("expiry %s further than %s in the future", raw, o.expiry)
+		return ErrInvalidHmacExpires
+	}
+	message.add("expires", raw)
+	return nil
+}
+
+// signedAuthority returns the authority configured with WithAuthority for signing.
+func (o *options) signedAuthority() string {
+	if len(o.authorities) > 0 {
+		return o.authorities[0]
+	}
+	return ""
+}
+
+// verifyAuthority checks :authority is one of the expected authorities and adds it to the message.
+func (o *options) verifyAuthority(md metadata.MD, message *canonical) error {
+	if !o.authority {
+		return nil
+	}
+	authority := getFirst(md, ":authority")
+	if authority == "" {
+		return ErrMissingAuthority
+	}
+	if len(o.authorities) == 0 {
+		message.add("authority", authority)
+		return nil
+	}
+	for _, expected := range o.authorities {
+		if strings.EqualFold(authority, expected) {
+			message.add("authority", authority)
+			return nil
+		}
+	}
+	logger.Printf("authority %s is not expected", authority)
+	return ErrInvalidAuthority
+}
+
+// verifyDate checks x-hmac-date is one of the dates accepted for derived keys.
+func (o *options) verifyDate(md metadata.MD) (string, error) {
+	if o.derived == nil {
+		return "", nil
+	}
+	date := getFirst(md, "x-hmac-date")
+	if date == "" {
+		return "", ErrMissingHmacDate
+	}
+	for _, valid := range validDates(o.now()) {
+		if date == valid {
+			return date, nil
+		}
+	}
+	logger.Printf("date %s of derived key is not accepted", date)
+	return "", ErrInvalidHmacDate
+}
+
+// methodOf returns the method of a message created by NewMessage, it is always the last field.
+func methodOf(message string) string {
+	if i := strings.LastIndex(message, "method="); i >= 0 {
+		return message[i+len("method="):]
+	}
+	return ""
+}
+
+// maxFields is the number of fields signed along with a message: timestamp, expires, authority and channel binding.
+const maxFields = 4
+
+// field is a key=value pair signed along with a message.
+type field struct {
+	key, value string
+}
+
+// canonical is a message and the fields signed along with it. Signing writes the parts to the hash instead of
+// concatenating them. head holds the start of a message encoded by the client interceptor up to the method, then
+// message is the method.
+type canonical struct {
+	head    []byte
+	message string
+	fields  [maxFields]field
+	n       int
+}
+
+// messageOf returns the canonical form of message.
+func messageOf(message string) canonical {
+	return canonical{message: message}
+}
+
+// add appends key=value to the message using the same separator as NewMessage.
+func (c *canonical) add(key, value string) {
+	c.fields[c.n] = field{key, value}
+	c.n++
+}
+
+// method returns the method of the message.
+func (c *canonical) method() string {
+	if c.head != nil {
+		return c.message
+	}
+	return methodOf(c.message)
+}
+
+// String returns the message with its fields.
+func (c *canonical) String() string {
+	var buf strings.Builder
+	buf.Write(c.head)
+	buf.WriteString(c.message)
+	for _, f := range c.fields[:c.n] {
+		buf.WriteString(";" + f.key + "=" + f.value)
+	}
+	return buf.String()
 }
 
 func getFirst(md metadata.MD, key string) string {
@@ -3,11 +3,16 @@ package hmac
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestNewMessage(t *testing.T) {
@@ -129,3 +134,203 @@ func Test_authForSecrets(t *testing.T) {
 		})
 	}
 }
+
+func Test_authForSecrets_timestamp(t *testing.T) {
+	now := time.Unix(1688212800, 0)
+	clock := ClockFunc(func() time.Time { return now })
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	signed := func(timestamp string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.MD{
+			"x-hmac-key-id":    []string{"key-id"},
+			"x-hmac-timestamp": []string{timestamp},
+			"x-hmac-signature": []string{String("secret", "plain-text;timestamp="+timestamp)},
+		})
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context //nolint:containedctx
+		want error
+	}{
+		{
+			"MissingTimestamp",
+			metadata.NewIncomingContext(context.Background(), metadata.MD{"x-hmac-signature": []string{"signature"}, "x-hmac-key-id": []string{"key-id"}}),
+			ErrMissingHmacTimestamp,
+		},
+		{"InvalidTimestamp", signed("yesterday"), ErrInvalidHmacTimestamp},
+		{"TooOld", signed(strconv.FormatInt(now.Add(-2*time.Minute).Unix(), 10)), ErrInvalidHmacTimestamp},
+		{"TooNew", signed(strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10)), ErrInvalidHmacTimestamp},
+		{"WithinSkew", signed(strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)), nil},
+		{"Now", signed(strconv.FormatInt(now.Unix(), 10)), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := authForSecrets(getSecret, WithClock(clock), WithTimestamp(time.Minute))
+			if got := auth(tt.ctx, "plain-text"); tt.want != got && !errors.Is(got, tt.want) { //nolint:errorlint
+				t.Errorf("auth() return got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	clock := ClockFunc(func() time.Time { return time.Unix(1688212800, 0) })
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	tests := []struct {
+		name string
+		opts []Option
+		want metadata.MD
+	}{
+		{
+			name: "Default",
+			want: metadata.Pairs("x-hmac-key-id", "key-id", "x-hmac-signature", String("secret", "plain-text")),
+		},
+		{
+			name: "Timestamp",
+			opts: []Option{WithClock(clock), WithTimestamp(time.Minute)},
+			want: metadata.Pairs("x-hmac-key-id", "key-id", "x-hmac-timestamp", "1688212800", "x-hmac-signature", String("secret", "plain-text;timestamp=1688212800")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := Sign("key-id", "secret", "plain-text", tt.opts...)
+			if !reflect.DeepEqual(md, tt.want) {
+				t.Errorf("Sign() got = %v, want %v", md, tt.want)
+			}
+			if err := Verify(context.Background(), md, "plain-text", getSecret, tt.opts...); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+			if err := Verify(context.Background(), md, "other-text", getSecret, tt.opts...); !errors.Is(err, ErrInvalidHmacSignature) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidHmacSignature)
+			}
+		})
+	}
+}
+
+func TestSignRequestVerifyRequest(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	for name, opts := range map[string][]Option{"Default": nil, "ContentDigest": {WithContentDigest(), WithTimestamp(time.Minute)}} {
+		t.Run(name, func(t *testing.T) {
+			req := wrapperspb.String("gopher")
+			md, err := SignRequest("key1", "secret1", req, "/svc/Method", opts...)
+			if err != nil {
+				t.Fatalf("SignRequest() error = %v", err)
+			}
+			server := NewServerInterceptor(getSecret, opts...)
+			handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil } //nolint:nilnil
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			if _, err = server.UnaryServerInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}, handler); err != nil {
+				t.Errorf("UnaryServerInterceptor() error = %v", err)
+			}
+			if err = VerifyRequest(context.Background(), md, req, "/svc/Method", getSecret, opts...); err != nil {
+				t.Errorf("VerifyRequest() error = %v", err)
+			}
+			if err = VerifyRequest(context.Background(), md, wrapperspb.String("other"), "/svc/Method", getSecret, opts...); err == nil {
+				t.Error("VerifyRequest() expected error for other request")
+			}
+			if err = VerifyRequest(context.Background(), md, req, "/svc/Other", getSecret, opts...); err == nil {
+				t.Error("VerifyRequest() expected error for other method")
+			}
+		})
+	}
+}
+
+func Test_authForSecrets_expires(t *testing.T) {
+	now := time.Unix(1688212800, 0)
+	clock := ClockFunc(func() time.Time { return now })
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	signed := func(expires string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.MD{
+			"x-hmac-key-id":    []string{"key-id"},
+			"x-hmac-expires":   []string{expires},
+			"x-hmac-signature": []string{String("secret", "plain-text;expires="+expires)},
+		})
+	}
+	unsigned := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"x-hmac-key-id":    []string{"key-id"},
+		"x-hmac-signature": []string{String("secret", "plain-text")},
+	})
+	tests := []struct {
+		name string
+		opts []Option
+		ctx  context.Context //nolint:containedctx
+		want error
+	}{
+		{"NotRequired", nil, unsigned, nil},
+		{"Missing", []Option{WithExpiry(time.Hour)}, unsigned, ErrMissingHmacExpires},
+		{"Invalid", nil, signed("tomorrow"), ErrInvalidHmacExpires},
+		{"Expired", nil, signed(strconv.FormatInt(now.Add(-time.Second).Unix(), 10)), ErrExpiredHmac},
+		{"ExpiredWithOption", []Option{WithExpiry(time.Hour)}, signed(strconv.FormatInt(now.Add(-time.Second).Unix(), 10)), ErrExpiredHmac},
+		{"Now", nil, signed(strconv.FormatInt(now.Unix(), 10)), nil},
+		{"Future", nil, signed(strconv.FormatInt(now.Add(24*time.Hour).Unix(), 10)), nil},
+		{"WithinTTL", []Option{WithExpiry(time.Hour)}, signed(strconv.FormatInt(now.Add(time.Hour).Unix(), 10)), nil},
+		{"BeyondTTL", []Option{WithExpiry(time.Hour)}, signed(strconv.FormatInt(now.Add(2*time.Hour).Unix(), 10)), ErrInvalidHmacExpires},
+		{"TamperedExpires", nil, metadata.NewIncomingContext(context.Background(), metadata.MD{
+			"x-hmac-key-id":    []string{"key-id"},
+			"x-hmac-expires":   []string{strconv.FormatInt(now.Add(time.Hour).Unix(), 10)},
+			"x-hmac-signature": []string{String("secret", "plain-text;expires="+strconv.FormatInt(now.Add(time.Minute).Unix(), 10))},
+		}), ErrInvalidHmacSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := authForSecrets(getSecret, append([]Option{WithClock(clock)}, tt.opts...)...)
+			if got := auth(tt.ctx, "plain-text"); tt.want != got && !errors.Is(got, tt.want) { //nolint:errorlint
+				t.Errorf("auth() return got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_authForSecrets_authority(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret", nil }
+	signed := func(authority, signedAuthority string) context.Context {
+		md := metadata.MD{
+			"x-hmac-key-id":    []string{"key-id"},
+			"x-hmac-signature": []string{String("secret", "plain-text;authority="+signedAuthority)},
+		}
+		if authority != "" {
+			md.Set(":authority", authority)
+		}
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		ctx  context.Context //nolint:containedctx
+		want error
+	}{
+		{"Missing", []Option{WithAuthority()}, signed("", "api.example.com"), ErrMissingAuthority},
+		{"Any", []Option{WithAuthority()}, signed("api.example.com", "api.example.com"), nil},
+		{"Expected", []Option{WithAuthority("staging.example.com", "api.example.com")}, signed("api.example.com", "api.example.com"), nil},
+		{"ExpectedIgnoringCase", []Option{WithAuthority("API.example.com")}, signed("api.example.com", "api.example.com"), nil},
+		{"Unexpected", []Option{WithAuthority("api.example.com")}, signed("staging.example.com", "staging.example.com"), ErrInvalidAuthority},
+		{"Replayed", []Option{WithAuthority()}, signed("api.example.com", "staging.example.com"), ErrInvalidHmacSignature},
+		{"NotBound", nil, signed("api.example.com", "api.example.com"), ErrInvalidHmacSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := authForSecrets(getSecret, tt.opts...)
+			if got := auth(tt.ctx, "plain-text"); tt.want != got && !errors.Is(got, tt.want) { //nolint:errorlint
+				t.Errorf("auth() return got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkAuthForSecrets(b *testing.B) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	req := wrapperspb.String("gopher")
+	message, _ := NewMessage(req, "/example.UserService/GetUser")
+	ctx := metadata.NewIncomingContext(context.Background(), Sign("key1", "secret1", message))
+	auth := authForSecrets(getSecret)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		message, err := NewMessage(req, "/example.UserService/GetUser")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = auth(ctx, message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
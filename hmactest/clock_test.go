@@ -0,0 +1,31 @@
+package hmactest_test
+
+import (
+	"testing"
+	"time"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+var _ hmac.Clock = (*hmactest.Clock)(nil)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := hmactest.NewClock(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Fatalf("Now() got = %v, want %v", got, start)
+	}
+	clock.Advance(time.Minute)
+	if got, want := clock.Now(), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("Advance() got = %v, want %v", got, want)
+	}
+	clock.Advance(-2 * time.Minute)
+	if got, want := clock.Now(), start.Add(-time.Minute); !got.Equal(want) {
+		t.Errorf("Advance() got = %v, want %v", got, want)
+	}
+	clock.Set(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("Set() got = %v, want %v", got, start)
+	}
+}
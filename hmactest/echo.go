@@ -0,0 +1,88 @@
+package hmactest
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// EchoMethod is the full method name of the unary Echo call.
+	EchoMethod = "/hmactest.Echo/Echo"
+	// EchoStreamMethod is the full method name of the server streaming Echo call.
+	EchoStreamMethod = "/hmactest.Echo/EchoStream"
+)
+
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hmactest.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Echo",
+			Handler:    echoHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EchoStream",
+			Handler:       echoStreamHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+// RegisterEcho registers the Echo service, which returns the request wrapperspb.StringValue as response.
+func RegisterEcho(r grpc.ServiceRegistrar) {
+	r.RegisterService(&echoServiceDesc, struct{}{})
+}
+
+// Echo calls EchoMethod and returns the echoed message.
+func Echo(ctx context.Context, cc grpc.ClientConnInterface, message string) (string, error) {
+	out := new(wrapperspb.StringValue)
+	if err := cc.Invoke(ctx, EchoMethod, wrapperspb.String(message), out); err != nil {
+		return "", err
+	}
+	return out.GetValue(), nil
+}
+
+// EchoStream calls EchoStreamMethod and returns the echoed message.
+func EchoStream(ctx context.Context, cc grpc.ClientConnInterface, message string) (string, error) {
+	stream, err := cc.NewStream(ctx, &echoServiceDesc.Streams[0], EchoStreamMethod)
+	if err != nil {
+		return "", err
+	}
+	if err = stream.SendMsg(wrapperspb.String(message)); err != nil {
+		return "", err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return "", err
+	}
+	out := new(wrapperspb.StringValue)
+	if err = stream.RecvMsg(out); err != nil {
+		return "", err
+	}
+	return out.GetValue(), nil
+}
+
+func echoHandler(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{FullMethod: EchoMethod}, handler)
+}
+
+func echoStreamHandler(_ interface{}, stream grpc.ServerStream) error {
+	in := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return stream.SendMsg(in)
+}
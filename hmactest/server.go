@@ -0,0 +1,73 @@
+package hmactest
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+const bufSize = 1024 * 1024
+
+// Server is an in-process gRPC server listening on a bufconn.Listener with the HMAC server interceptor installed.
+// The Echo service is always registered.
+type Server struct {
+	// Interceptor installed on the server, can be used to ignore methods.
+	Interceptor hmac.ServerInterceptor
+	listener    *bufconn.Listener
+	server      *grpc.Server
+	opts        []hmac.Option
+}
+
+// NewServer starts a Server authenticating requests using getSecret and opts.
+// register, if not nil, is called to register additional services before the server starts.
+func NewServer(getSecret hmac.GetSecret, register func(grpc.ServiceRegistrar), opts ...hmac.Option) *Server {
+	interceptor := hmac.NewServerInterceptor(getSecret, opts...)
+	s := &Server{
+		Interceptor: interceptor,
+		listener:    bufconn.Listen(bufSize),
+		server:      grpc.NewServer(interceptor.UnaryInterceptor(), interceptor.StreamInterceptor()),
+		opts:        opts,
+	}
+	RegisterEcho(s.server)
+	if register != nil {
+		register(s.server)
+	}
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+	return s
+}
+
+// Dial returns a client connection to the server without HMAC authentication.
+func (s *Server) Dial(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	return grpc.NewClient("passthrough:///bufnet", opts...)
+}
+
+// DialSigned returns a client connection to the server signing requests with keyID, secret and the options the
+// server was created with. The tampers are applied in order to the signed metadata of every request.
+func (s *Server) DialSigned(keyID, secret string, tampers ...Tamper) (*grpc.ClientConn, error) {
+	interceptor := hmac.NewClientInterceptor(keyID, secret, s.opts...)
+	unary := []grpc.UnaryClientInterceptor{interceptor.UnaryClientInterceptor}
+	stream := []grpc.StreamClientInterceptor{interceptor.StreamClientInterceptor}
+	for _, tamper := range tampers {
+		unary = append(unary, tamper.UnaryClientInterceptor)
+		stream = append(stream, tamper.StreamClientInterceptor)
+	}
+	return s.Dial(grpc.WithChainUnaryInterceptor(unary...), grpc.WithChainStreamInterceptor(stream...))
+}
+
+// Close stops the server and closes the listener.
+func (s *Server) Close() {
+	s.server.Stop()
+}
@@ -0,0 +1,160 @@
+package hmactest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+func getSecret(_ context.Context, keyID string) (string, error) {
+	if keyID == "key1" {
+		return "secret1", nil
+	}
+	return "", nil
+}
+
+func TestServer(t *testing.T) {
+	srv := hmactest.NewServer(getSecret, nil)
+	defer srv.Close()
+	tests := []struct {
+		name    string
+		keyID   string
+		secret  string
+		tampers []hmactest.Tamper
+		want    codes.Code
+	}{
+		{name: "Valid", keyID: "key1", secret: "secret1", want: codes.OK},
+		{name: "WrongSecret", keyID: "key1", secret: "secret2", want: codes.Unauthenticated},
+		{name: "UnknownKeyID", keyID: "key2", secret: "secret1", want: codes.Unauthenticated},
+		{name: "BadSignature", keyID: "key1", secret: "secret1", tampers: []hmactest.Tamper{hmactest.BadSignature()}, want: codes.Unauthenticated},
+		{name: "MissingKeyID", keyID: "key1", secret: "secret1", tampers: []hmactest.Tamper{hmactest.Without("x-hmac-key-id")}, want: codes.Unauthenticated},
+		{name: "MissingSignature", keyID: "key1", secret: "secret1", tampers: []hmactest.Tamper{hmactest.Without("x-hmac-signature")}, want: codes.Unauthenticated},
+		{name: "OtherKeyID", keyID: "key1", secret: "secret1", tampers: []hmactest.Tamper{hmactest.Set("x-hmac-key-id", "key2")}, want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := srv.DialSigned(tt.keyID, tt.secret, tt.tampers...)
+			if err != nil {
+				t.Fatalf("DialSigned() error = %v", err)
+			}
+			defer conn.Close()
+			got, err := hmactest.Echo(context.Background(), conn, "hello")
+			if status.Code(err) != tt.want {
+				t.Fatalf("Echo() error = %v, want code %v", err, tt.want)
+			}
+			if err == nil && got != "hello" {
+				t.Errorf("Echo() got = %v, want hello", got)
+			}
+			_, err = hmactest.EchoStream(context.Background(), conn, "hello")
+			if status.Code(err) != tt.want {
+				t.Errorf("EchoStream() error = %v, want code %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_unsigned(t *testing.T) {
+	srv := hmactest.NewServer(getSecret, nil)
+	defer srv.Close()
+	conn, err := srv.Dial()
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	if _, err = hmactest.Echo(context.Background(), conn, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v, want code %v", err, codes.Unauthenticated)
+	}
+	srv.Interceptor.IgnoredMethods(hmactest.EchoMethod)
+	if _, err = hmactest.Echo(context.Background(), conn, "hello"); err != nil {
+		t.Errorf("Echo() error = %v for ignored method", err)
+	}
+}
+
+func TestServer_replay(t *testing.T) {
+	clock := hmactest.NewClock(time.Now())
+	srv := hmactest.NewServer(getSecret, nil, hmac.WithClock(clock), hmac.WithTimestamp(time.Minute))
+	defer srv.Close()
+	var recorded metadata.MD
+	signed, err := srv.DialSigned("key1", "secret1", hmactest.Record(&recorded))
+	if err != nil {
+		t.Fatalf("DialSigned() error = %v", err)
+	}
+	defer signed.Close()
+	if _, err = hmactest.Echo(context.Background(), signed, "hello"); err != nil {
+		t.Fatalf("Echo() error = %v", err)
+	}
+	unsigned, err := srv.Dial()
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer unsigned.Close()
+	if _, err = hmactest.Echo(hmactest.Replay(context.Background(), recorded), unsigned, "hello"); err != nil {
+		t.Errorf("Echo() error = %v for replay within skew", err)
+	}
+	clock.Advance(2 * time.Minute)
+	if _, err = hmactest.Echo(hmactest.Replay(context.Background(), recorded), unsigned, "hello"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Echo() error = %v for replay outside skew, want code %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestServer_authority(t *testing.T) {
+	srv := hmactest.NewServer(getSecret, nil, hmac.WithAuthority("bufnet"))
+	defer srv.Close()
+	tests := []struct {
+		name string
+		opts []hmac.Option
+		want codes.Code
+	}{
+		{name: "FromTarget", opts: []hmac.Option{hmac.WithAuthority()}, want: codes.OK},
+		{name: "Explicit", opts: []hmac.Option{hmac.WithAuthority("bufnet")}, want: codes.OK},
+		{name: "OtherEnvironment", opts: []hmac.Option{hmac.WithAuthority("production")}, want: codes.Unauthenticated},
+		{name: "NotBound", want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := hmac.NewClientInterceptor("key1", "secret1", tt.opts...)
+			conn, err := srv.Dial(interceptor.WithUnaryInterceptor(), interceptor.WithStreamInterceptor())
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+			if _, err = hmactest.Echo(context.Background(), conn, "hello"); status.Code(err) != tt.want {
+				t.Errorf("Echo() error = %v, want code %v", err, tt.want)
+			}
+			if _, err = hmactest.EchoStream(context.Background(), conn, "hello"); status.Code(err) != tt.want {
+				t.Errorf("EchoStream() error = %v, want code %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_register(t *testing.T) {
+	registered := false
+	srv := hmactest.NewServer(getSecret, func(grpc.ServiceRegistrar) { registered = true })
+	defer srv.Close()
+	if !registered {
+		t.Errorf("NewServer() expected register to be called")
+	}
+}
+
+func TestNewServerStream(t *testing.T) {
+	interceptor := hmac.NewServerInterceptor(getSecret)
+	message, _ := hmac.NewMessage(nil, "method1")
+	ss := hmactest.NewServerStream(metadata.Pairs("x-hmac-key-id", "key1", "x-hmac-signature", hmac.String("secret1", message)))
+	handlerCalled := false
+	handler := func(interface{}, grpc.ServerStream) error { handlerCalled = true; return nil }
+	if err := interceptor.StreamServerInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "method1"}, handler); err != nil {
+		t.Fatalf("StreamServerInterceptor() error = %v", err)
+	}
+	if !handlerCalled {
+		t.Errorf("StreamServerInterceptor() expected handler to be called")
+	}
+}
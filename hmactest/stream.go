@@ -0,0 +1,25 @@
+package hmactest
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ServerStream is a fake grpc.ServerStream returning Ctx from Context, for calling StreamServerInterceptor directly.
+// Other methods panic unless ServerStream is set.
+type ServerStream struct {
+	grpc.ServerStream
+	Ctx context.Context //nolint:containedctx
+}
+
+// NewServerStream returns a ServerStream with md as incoming metadata.
+func NewServerStream(md metadata.MD) *ServerStream {
+	return &ServerStream{Ctx: metadata.NewIncomingContext(context.Background(), md)}
+}
+
+// Context returns Ctx.
+func (s *ServerStream) Context() context.Context {
+	return s.Ctx
+}
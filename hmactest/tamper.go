@@ -0,0 +1,65 @@
+package hmactest
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Tamper modifies the signed outgoing metadata of a request, used to craft requests that must fail authentication.
+type Tamper func(md metadata.MD)
+
+// BadSignature replaces x-hmac-signature with a well-formed signature that does not match the request.
+func BadSignature() Tamper {
+	return Set("x-hmac-signature", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+}
+
+// Without removes the given metadata keys, for example "x-hmac-key-id" to send a request missing the key id.
+func Without(keys ...string) Tamper {
+	return func(md metadata.MD) {
+		for _, key := range keys {
+			md.Delete(key)
+		}
+	}
+}
+
+// Set replaces the value of metadata key.
+func Set(key, value string) Tamper {
+	return func(md metadata.MD) {
+		md.Set(key, value)
+	}
+}
+
+// Record copies the signed metadata of the last request into dst, which can then be sent again using Replay.
+func Record(dst *metadata.MD) Tamper {
+	return func(md metadata.MD) {
+		*dst = md.Copy()
+	}
+}
+
+// Replay returns a context sending previously recorded metadata.
+// Use it with a connection returned by Server.Dial so the request is not signed again.
+func Replay(ctx context.Context, md metadata.MD) context.Context {
+	return metadata.NewOutgoingContext(ctx, md.Copy())
+}
+
+// UnaryClientInterceptor a grpc.UnaryClientInterceptor applying the Tamper to outgoing metadata.
+func (t Tamper) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(t.apply(ctx), method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor a grpc.StreamClientInterceptor applying the Tamper to outgoing metadata.
+func (t Tamper) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(t.apply(ctx), desc, cc, method, opts...)
+}
+
+func (t Tamper) apply(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	md = md.Copy()
+	t(md)
+	return metadata.NewOutgoingContext(ctx, md)
+}
@@ -0,0 +1,174 @@
+package hmactest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+// VectorsVersion is incremented whenever existing vectors change, adding vectors does not change the version.
+const VectorsVersion = 1
+
+// VectorSet is the file format of the test vectors in testdata/vectors.
+type VectorSet struct {
+	Version   int      `json:"version"`
+	Algorithm string   `json:"algorithm"`
+	Vectors   []Vector `json:"vectors"`
+}
+
+// Vector describes the canonical message and metadata the client interceptor produces for a request.
+type Vector struct {
+	Name   string `json:"name"`
+	KeyID  string `json:"key_id"`
+	Secret string `json:"secret"`
+	Method string `json:"method"`
+	// Request is the request as encoded by encoding/json, null for streaming calls.
+	Request json.RawMessage `json:"request"`
+	// Timestamp is the client time in unix seconds when hmac.WithTimestamp is used.
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// DerivedKey is the base64 encoded key derived from the secret when hmac.WithDerivedKeys is used, the signature is
+	// generated with it instead of the secret.
+	DerivedKey string `json:"derived_key,omitempty"`
+	Message    string `json:"message"`
+	Signature  string `json:"signature"`
+	// Metadata sent by the client interceptor.
+	Metadata map[string]string `json:"metadata"`
+}
+
+type vectorInput struct {
+	name      string
+	method    string
+	req       interface{}
+	timestamp int64
+	// derived is the client time in unix seconds to sign with hmac.WithDerivedKeys.
+	derived int64
+}
+
+func vectorInputs() ([]vectorInput, error) {
+	st, err := structpb.NewStruct(map[string]interface{}{"name": "gopher", "age": 13, "tags": []interface{}{"a", "b"}})
+	if err != nil {
+		return nil, err
+	}
+	return []vectorInput{
+		{name: "NoRequest", method: "/example.UserService/ListUsers"},
+		{name: "NoExportedFields", method: "/example.UserService/GetUser", req: &struct{ name string }{"gopher"}},
+		{name: "EmptyProto", method: "/example.UserService/GetUser", req: wrapperspb.String("")},
+		{name: "String", method: "/example.UserService/GetUser", req: wrapperspb.String("gopher")},
+		{name: "HTMLEscaped", method: "/example.UserService/GetUser", req: wrapperspb.String("<a href=\"x\">&</a>")},
+		{name: "Unicode", method: "/example.UserService/GetUser", req: wrapperspb.String("héllo, 世界 🚀")},
+		{name: "Int64", method: "/example.UserService/GetUser", req: wrapperspb.Int64(9007199254740993)},
+		{name: "Double", method: "/example.UserService/GetUser", req: wrapperspb.Double(0.1)},
+		{name: "Bool", method: "/example.UserService/GetUser", req: wrapperspb.Bool(true)},
+		{name: "Bytes", method: "/example.UserService/GetUser", req: wrapperspb.Bytes([]byte{0, 1, 2, 253, 254, 255})},
+		{name: "Timestamp", method: "/example.UserService/GetUser", req: timestamppb.New(time.Date(2023, 7, 1, 12, 0, 0, 500, time.UTC))},
+		{name: "Struct", method: "/example.UserService/GetUser", req: st},
+		{name: "WithTimestamp", method: "/example.UserService/GetUser", req: wrapperspb.String("gopher"), timestamp: 1688212800},
+		{name: "DerivedKey", method: "/example.UserService/GetUser", req: wrapperspb.String("gopher"), derived: 1688212800},
+	}, nil
+}
+
+// GenerateVectors produces the test vectors using the client interceptor.
+func GenerateVectors() (*VectorSet, error) {
+	inputs, err := vectorInputs()
+	if err != nil {
+		return nil, err
+	}
+	set := &VectorSet{Version: VectorsVersion, Algorithm: "HMAC-SHA512/256", Vectors: make([]Vector, 0, len(inputs))}
+	for i, in := range inputs {
+		v, err := generateVector(in, fmt.Sprintf("key-%d", i+1), fmt.Sprintf("secret-%d", i+1))
+		if err != nil {
+			return nil, fmt.Errorf("vector %s: %w", in.name, err)
+		}
+		set.Vectors = append(set.Vectors, *v)
+	}
+	return set, nil
+}
+
+// WriteVectors writes GenerateVectors output as indented JSON.
+func WriteVectors(w io.Writer) error {
+	set, err := GenerateVectors()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(set)
+}
+
+func generateVector(in vectorInput, keyID, secret string) (*Vector, error) {
+	var opts []hmac.Option
+	if in.timestamp != 0 {
+		opts = append(opts, hmac.WithClock(hmac.ClockFunc(func() time.Time { return time.Unix(in.timestamp, 0) })), hmac.WithTimestamp(time.Minute))
+	}
+	if in.derived != 0 {
+		opts = append(opts, hmac.WithClock(hmac.ClockFunc(func() time.Time { return time.Unix(in.derived, 0) })), hmac.WithDerivedKeys())
+	}
+	md, err := captureMetadata(hmac.NewClientInterceptor(keyID, secret, opts...), in.method, in.req)
+	if err != nil {
+		return nil, err
+	}
+	message, err := hmac.NewMessage(in.req, in.method)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vector{
+		Name:      in.name,
+		KeyID:     keyID,
+		Secret:    secret,
+		Method:    in.method,
+		Request:   json.RawMessage("null"),
+		Timestamp: in.timestamp,
+		Message:   message,
+		Signature: md["x-hmac-signature"],
+		Metadata:  md,
+	}
+	if in.req != nil {
+		if v.Request, err = json.Marshal(in.req); err != nil {
+			return nil, err
+		}
+	}
+	if ts, ok := md["x-hmac-timestamp"]; ok {
+		v.Message += ";timestamp=" + ts
+	}
+	key := secret
+	if date, ok := md["x-hmac-date"]; ok {
+		derived := hmac.DeriveKey(hmac.NewSecret([]byte(secret)), date, in.method)
+		v.DerivedKey, key = base64.StdEncoding.EncodeToString(derived), string(derived)
+	}
+	if hmac.String(key, v.Message) != v.Signature {
+		return nil, fmt.Errorf("signature does not match message %q", v.Message)
+	}
+	return v, nil
+}
+
+func captureMetadata(interceptor hmac.ClientInterceptor, method string, req interface{}) (map[string]string, error) {
+	captured := make(map[string]string)
+	capture := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		for k, v := range md {
+			captured[k] = strings.Join(v, ",")
+		}
+		return nil
+	}
+	if req == nil {
+		streamer := func(ctx context.Context, _ *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, capture(ctx, method, nil, nil, cc, opts...)
+		}
+		_, err := interceptor.StreamClientInterceptor(context.Background(), &grpc.StreamDesc{}, nil, method, streamer)
+		return captured, err
+	}
+	return captured, interceptor.UnaryClientInterceptor(context.Background(), method, req, nil, nil, capture)
+}
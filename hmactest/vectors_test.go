@@ -0,0 +1,33 @@
+package hmactest_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+)
+
+var update = flag.Bool("update", false, "regenerate testdata/vectors")
+
+const vectorsFile = "../testdata/vectors/v1.json"
+
+func TestGenerateVectors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := hmactest.WriteVectors(buf); err != nil {
+		t.Fatalf("WriteVectors() error = %v", err)
+	}
+	if *update {
+		if err := os.WriteFile(vectorsFile, buf.Bytes(), 0o600); err != nil {
+			t.Fatalf("failed to update %s: %v", vectorsFile, err)
+		}
+	}
+	want, err := os.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", vectorsFile, err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteVectors() does not match %s, run go test ./hmactest -update if the change is intended and increment VectorsVersion when existing vectors changed", vectorsFile)
+	}
+}
@@ -0,0 +1,57 @@
+// Package httphmac authenticates net/http requests with the signatures of go-grpc-hmac, e.g. in front of a
+// grpc-gateway forwarding the authenticated key id to the gRPC server interceptor.
+package httphmac
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Message returns the message of a HTTP request signed in place of NewMessage, covering the SHA-256 digest of the body,
+// the query parameters sorted by key, the HTTP method and the path as
+// "content-digest=sha-256=:<base64>:;query=<query>;method=<http method> <path>".
+// The body is read up to maxBodySize bytes and replaced, so it can be read again.
+func Message(r *http.Request, maxBodySize int64) (string, error) {
+	digest, err := bodyDigest(r, maxBodySize)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	query := r.URL.Query().Encode()
+	path := r.URL.EscapedPath()
+	buf.Grow(len("content-digest=;query=;method= ") + len(digest) + len(query) + len(r.Method) + len(path))
+	buf.WriteString("content-digest=")
+	buf.WriteString(digest)
+	buf.WriteString(";query=")
+	buf.WriteString(query)
+	buf.WriteString(";method=")
+	buf.WriteString(r.Method)
+	buf.WriteString(" ")
+	buf.WriteString(path)
+	return buf.String(), nil
+}
+
+// bodyDigest returns the digest of the request body in the format of x-hmac-content-digest and replaces the body.
+func bodyDigest(r *http.Request, maxBodySize int64) (string, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read body: %w", err)
+		}
+		if int64(len(data)) > maxBodySize {
+			return "", ErrBodyTooLarge
+		}
+		body = data
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	}
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":", nil
+}
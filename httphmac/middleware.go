@@ -0,0 +1,184 @@
+package httphmac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+const (
+	// defaultMaxBodySize is the size above which request bodies are rejected unless changed with WithMaxBodySize.
+	defaultMaxBodySize = 4 << 20
+	// metadataHeaderPrefix is the prefix of headers forwarded as gRPC metadata by grpc-gateway.
+	metadataHeaderPrefix = "Grpc-Metadata-"
+	// forwardedPrefix is the prefix of the metadata set by hmac.ForwardKeyID.
+	forwardedPrefix = "x-hmac-forwarded-"
+)
+
+// ErrBodyTooLarge is returned when the body of a request is larger than the maximum body size.
+var ErrBodyTooLarge = errors.New("httphmac: request body too large")
+
+// Option configures the middleware and transports.
+type Option func(*options)
+
+type options struct {
+	hmac        []hmac.Option
+	maxBodySize int64
+	forwarding  *hmac.Secret
+	// forwardedMethod returns the gRPC method a request is forwarded to.
+	forwardedMethod func(r *http.Request) string
+	errorHandler    func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// WithOptions sets the options used to sign and verify signatures, e.g. hmac.WithTimestamp. hmac.WithAuthority verifies
+// the Host of the request, transports sign its first authority. hmac.WithChannelBinding and hmac.WithContentDigest do
+// not apply to HTTP requests.
+func WithOptions(opts ...hmac.Option) Option {
+	return func(o *options) {
+		o.hmac = append(o.hmac, opts...)
+	}
+}
+
+// WithMaxBodySize sets the maximum size of request bodies read to sign or verify the signature, defaults to 4 MiB.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxBodySize = size
+	}
+}
+
+// WithForwarding forwards the key id of authenticated requests to a grpc-gateway as the Grpc-Metadata- headers of
+// hmac.ForwardKeyID signed with secret, so a gRPC server configured with hmac.WithForwardedKeyID trusts the key id
+// instead of verifying the request again. The forwarded key id is bound to the full gRPC method returned by method, the
+// method the grpc-gateway routes the request to, and not forwarded if it returns an empty string. The timestamp is the
+// time of the hmac.WithClock passed with WithOptions. Forwarded headers sent by clients are always removed. An empty
+// secret is no secret, key ids are not forwarded then.
+func WithForwarding(secret string, method func(r *http.Request) string) Option {
+	return func(o *options) {
+		if secret == "" {
+			o.forwarding = nil
+			return
+		}
+		o.forwarding = hmac.NewSecret([]byte(secret))
+		o.forwardedMethod = method
+	}
+}
+
+// WithErrorHandler sets the handler writing the response of requests failing verification. Defaults to 401
+// Unauthorized for invalid signatures, 413 Request Entity Too Large for ErrBodyTooLarge and 500 Internal Server Error
+// otherwise.
+func WithErrorHandler(handler func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(o *options) {
+		o.errorHandler = handler
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{maxBodySize: defaultMaxBodySize, errorHandler: defaultErrorHandler}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// keyIDKey is the context key of the authenticated key id.
+type keyIDKey struct{}
+
+// KeyID returns the key id of the request authenticated by the middleware.
+func KeyID(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(keyIDKey{}).(string)
+	return keyID, ok
+}
+
+// NewMiddleware returns a net/http middleware verifying requests signed with the secret returned by getSecret over the
+// Message of the request. The x-hmac headers are the same as the metadata of the gRPC interceptors, the authenticated
+// key id is available to handlers with KeyID.
+func NewMiddleware(getSecret hmac.GetSecret, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts...)
+	getSecretKey := getSecret.Keyed()
+	return o.middleware(func(ctx context.Context, md metadata.MD, message string) error {
+		return hmac.VerifySecret(ctx, md, message, getSecretKey, o.hmac...)
+	})
+}
+
+// NewEd25519Middleware is like NewMiddleware verifying Ed25519 signatures with the public key returned by getPublicKey.
+func NewEd25519Middleware(getPublicKey hmac.GetPublicKey, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts...)
+	return o.middleware(func(ctx context.Context, md metadata.MD, message string) error {
+		return hmac.VerifyEd25519(ctx, md, message, getPublicKey, o.hmac...)
+	})
+}
+
+func (o *options) middleware(verify func(ctx context.Context, md metadata.MD, message string) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			removeForwarded(r.Header)
+			message, err := Message(r, o.maxBodySize)
+			if err != nil {
+				o.errorHandler(w, r, err)
+				return
+			}
+			md := metadataOf(r)
+			if err = verify(r.Context(), md, message); err != nil {
+				o.errorHandler(w, r, err)
+				return
+			}
+			keyID := md.Get("x-hmac-key-id")[0]
+			o.forward(r, keyID)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyIDKey{}, keyID)))
+		})
+	}
+}
+
+// forward adds the headers forwarding keyID to the gRPC method of r, if forwarding is enabled.
+func (o *options) forward(r *http.Request, keyID string) {
+	if o.forwarding == nil {
+		return
+	}
+	method := o.forwardedMethod(r)
+	if method == "" {
+		return
+	}
+	for k, v := range hmac.ForwardKeyID(keyID, method, o.forwarding, o.hmac...) {
+		r.Header[metadataHeaderPrefix+textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+}
+
+// metadataOf returns the x-hmac headers and the Host of r as metadata.
+func metadataOf(r *http.Request) metadata.MD {
+	md := metadata.MD{":authority": {r.Host}}
+	for k, v := range r.Header {
+		if key := strings.ToLower(k); strings.HasPrefix(key, "x-hmac-") {
+			md[key] = v
+		}
+	}
+	return md
+}
+
+// removeForwarded removes forwarded key id headers, with or without the grpc-gateway prefix, sent by the client.
+func removeForwarded(header http.Header) {
+	for k := range header {
+		key := strings.TrimPrefix(strings.ToLower(k), strings.ToLower(metadataHeaderPrefix))
+		if strings.HasPrefix(key, forwardedPrefix) {
+			header.Del(k)
+		}
+	}
+}
+
+func defaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case status.Code(err) == codes.Unauthenticated:
+		http.Error(w, status.Convert(err).Message(), http.StatusUnauthorized)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,205 @@
+package httphmac
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+func getSecret(_ context.Context, keyId string) (string, error) {
+	if keyId == "key1" {
+		return "secret1", nil
+	}
+	return "", nil
+}
+
+// signed returns a request signed with keyID and secret.
+func signed(t *testing.T, method, target, body, keyID, secret string, opts ...hmac.Option) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	message, err := Message(r, defaultMaxBodySize)
+	if err != nil {
+		t.Fatalf("Message() error = %v", err)
+	}
+	for k, v := range hmac.Sign(keyID, secret, message, opts...) {
+		r.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	return r
+}
+
+// echo responds with the authenticated key id and body of the request.
+func echo(w http.ResponseWriter, r *http.Request) {
+	keyID, _ := KeyID(r.Context())
+	body, _ := io.ReadAll(r.Body)
+	_, _ = io.WriteString(w, keyID+":"+string(body))
+}
+
+func TestMessage(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/users/a%2Fb?b=2&a=1&a=0", strings.NewReader("{}"))
+	got, err := Message(r, defaultMaxBodySize)
+	want := "content-digest=sha-256=:RBNvo1WzZ4oRRq0W9+hknpT7T8If536DEMBg9hyq/4o=:;query=a=1&a=0&b=2;method=POST /v1/users/a%2Fb"
+	if err != nil || got != want {
+		t.Errorf("Message() got = %v, %v, want %v", got, err, want)
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != "{}" {
+		t.Errorf("Message() body = %q after reading, want %q", body, "{}")
+	}
+	if _, err = Message(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")), 1); err != ErrBodyTooLarge {
+		t.Errorf("Message() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
+
+func TestNewMiddleware(t *testing.T) {
+	handler := NewMiddleware(getSecret, WithOptions(hmac.WithTimestamp(time.Minute)))(http.HandlerFunc(echo))
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "Valid",
+			request: func() *http.Request {
+				return signed(t, http.MethodPost, "/v1/users?a=1", "{}", "key1", "secret1", hmac.WithTimestamp(time.Minute))
+			},
+			wantCode: http.StatusOK,
+			wantBody: "key1:{}",
+		},
+		{
+			name:     "Unsigned",
+			request:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/v1/users", nil) },
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "TamperedBody",
+			request: func() *http.Request {
+				r := signed(t, http.MethodPost, "/v1/users", "{}", "key1", "secret1", hmac.WithTimestamp(time.Minute))
+				r.Body = io.NopCloser(strings.NewReader(`{"admin":true}`))
+				return r
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "TamperedQuery",
+			request: func() *http.Request {
+				r := signed(t, http.MethodGet, "/v1/users?a=1", "", "key1", "secret1", hmac.WithTimestamp(time.Minute))
+				r.URL.RawQuery = "a=2"
+				return r
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "TamperedMethod",
+			request: func() *http.Request {
+				r := signed(t, http.MethodGet, "/v1/users", "", "key1", "secret1", hmac.WithTimestamp(time.Minute))
+				r.Method = http.MethodDelete
+				return r
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "UnknownKeyID",
+			request: func() *http.Request {
+				return signed(t, http.MethodGet, "/", "", "key2", "secret1", hmac.WithTimestamp(time.Minute))
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "MissingTimestamp",
+			request:  func() *http.Request { return signed(t, http.MethodGet, "/", "", "key1", "secret1") },
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.request())
+			if w.Code != tt.wantCode {
+				t.Errorf("ServeHTTP() code = %v, want %v, body %q", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewMiddleware_bodyTooLarge(t *testing.T) {
+	handler := NewMiddleware(getSecret, WithMaxBodySize(1))(http.HandlerFunc(echo))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, signed(t, http.MethodPost, "/", "{}", "key1", "secret1"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("ServeHTTP() code = %v, want %v", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestWithForwarding(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := hmac.WithClock(hmac.ClockFunc(func() time.Time { return now }))
+	var forwarded metadata.MD
+	method := func(r *http.Request) string {
+		if r.URL.Path == "/v1/users" {
+			return "/svc/Method"
+		}
+		return ""
+	}
+	handler := NewMiddleware(getSecret, WithForwarding("gateway", method), WithOptions(clock))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// grpc-gateway forwards Grpc-Metadata- headers without the prefix as metadata
+		forwarded = metadata.MD{}
+		for k, v := range r.Header {
+			if key, ok := strings.CutPrefix(k, metadataHeaderPrefix); ok {
+				forwarded.Append(strings.ToLower(key), v...)
+			}
+		}
+	}))
+	r := signed(t, http.MethodGet, "/v1/users", "", "key1", "secret1")
+	r.Header.Set("Grpc-Metadata-X-Hmac-Forwarded-Key-Id", "admin")
+	r.Header.Set("X-Hmac-Forwarded-Signature", "forged")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	if got := forwarded.Get("x-hmac-forwarded-key-id"); len(got) != 1 || got[0] != "key1" {
+		t.Errorf("forwarded key id = %v, want [key1]", got)
+	}
+	if got := forwarded.Get("x-hmac-forwarded-timestamp"); len(got) != 1 || got[0] != "1700000000" {
+		t.Errorf("forwarded timestamp = %v, want the time of the clock", got)
+	}
+	if r.Header.Get("X-Hmac-Forwarded-Signature") != "" {
+		t.Error("expected forwarded headers sent by the client to be removed")
+	}
+	auth := hmac.NewServerInterceptor(getSecret, hmac.WithForwardedKeyID("gateway", time.Minute), clock)
+	for fullMethod, wantErr := range map[string]bool{"/svc/Method": false, "/svc/Other": true} {
+		handlerCalled := false
+		_, err := auth.UnaryServerInterceptor(metadata.NewIncomingContext(context.Background(), forwarded), nil, &grpc.UnaryServerInfo{FullMethod: fullMethod}, func(context.Context, interface{}) (interface{}, error) {
+			handlerCalled = true
+			return nil, nil //nolint:nilnil
+		})
+		if (err != nil) != wantErr || handlerCalled == wantErr {
+			t.Errorf("UnaryServerInterceptor() on %s error = %v, handler called %v", fullMethod, err, handlerCalled)
+		}
+	}
+	forwarded = nil
+	handler.ServeHTTP(httptest.NewRecorder(), signed(t, http.MethodGet, "/v1/other", "", "key1", "secret1"))
+	if got := forwarded.Get("x-hmac-forwarded-key-id"); len(got) != 0 {
+		t.Errorf("forwarded key id = %v for a request without gRPC method, want none", got)
+	}
+}
+
+func TestWithForwarding_emptySecret(t *testing.T) {
+	forwarded := "not called"
+	method := func(*http.Request) string { return "/svc/Method" }
+	handler := NewMiddleware(getSecret, WithForwarding("", method))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(metadataHeaderPrefix + "X-Hmac-Forwarded-Key-Id")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), signed(t, http.MethodGet, "/v1/users", "", "key1", "secret1"))
+	if forwarded != "" {
+		t.Errorf("forwarded key id = %q with an empty secret, want none", forwarded)
+	}
+}
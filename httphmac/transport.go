@@ -0,0 +1,88 @@
+package httphmac
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/metadata"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+// transport signs requests before sending them with base.
+type transport struct {
+	base        http.RoundTripper
+	sign        func(message string) metadata.MD
+	maxBodySize int64
+}
+
+// NewTransport returns a http.RoundTripper adding the x-hmac headers of the Message of each request signed with keyID
+// and secret, as verified by NewMiddleware, before sending it with base. If base is nil http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, keyID, secret string, opts ...Option) http.RoundTripper {
+	return NewSecretTransport(base, keyID, hmac.NewSecret([]byte(secret)), opts...)
+}
+
+// NewSecretTransport is like NewTransport using a Secret.
+func NewSecretTransport(base http.RoundTripper, keyID string, secret *hmac.Secret, opts ...Option) http.RoundTripper {
+	o := newOptions(opts...)
+	return o.transport(base, func(message string) metadata.MD {
+		return hmac.SignSecret(keyID, secret, message, o.hmac...)
+	})
+}
+
+// NewEd25519Transport is like NewTransport signing with an Ed25519 private key, as verified by NewEd25519Middleware.
+func NewEd25519Transport(base http.RoundTripper, keyID string, privateKey ed25519.PrivateKey, opts ...Option) http.RoundTripper {
+	o := newOptions(opts...)
+	return o.transport(base, func(message string) metadata.MD {
+		return hmac.SignEd25519(keyID, privateKey, message, o.hmac...)
+	})
+}
+
+func (o *options) transport(base http.RoundTripper, sign func(message string) metadata.MD) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base, sign, o.maxBodySize}
+}
+
+// RoundTrip signs a clone of req and sends it with the base http.RoundTripper. The clone reads the body from
+// req.GetBody if set, otherwise the body is buffered, so the body of req is only closed.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := cloneBody(req, t.maxBodySize)
+		if err != nil {
+			return nil, err
+		}
+		signed.Body = body
+	}
+	message, err := Message(signed, t.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.sign(message) {
+		signed.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	return t.base.RoundTrip(signed) //nolint:wrapcheck
+}
+
+// cloneBody returns a copy of the body of req from req.GetBody or by buffering at most maxBodySize+1 bytes, Message
+// rejects larger bodies. The body of req is closed as required of a http.RoundTripper.
+func cloneBody(req *http.Request, maxBodySize int64) (io.ReadCloser, error) {
+	defer req.Body.Close()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get body: %w", err)
+		}
+		return body, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,140 @@
+package httphmac
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+func TestNewTransport(t *testing.T) {
+	opts := []Option{WithOptions(hmac.WithTimestamp(time.Minute), hmac.WithDerivedKeys())}
+	server := httptest.NewServer(NewMiddleware(getSecret, opts...)(http.HandlerFunc(echo)))
+	defer server.Close()
+	tests := []struct {
+		name      string
+		transport http.RoundTripper
+		wantCode  int
+		wantBody  string
+	}{
+		{name: "Signed", transport: NewTransport(nil, "key1", "secret1", opts...), wantCode: http.StatusOK, wantBody: "key1:{}"},
+		{name: "WrongSecret", transport: NewTransport(nil, "key1", "secret2", opts...), wantCode: http.StatusUnauthorized},
+		{name: "MissingTimestamp", transport: NewTransport(nil, "key1", "secret1"), wantCode: http.StatusUnauthorized},
+		{name: "Unsigned", transport: http.DefaultTransport, wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: tt.transport}
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/v1/users?b=2&a=1", strings.NewReader("{}"))
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+			if res.StatusCode != tt.wantCode {
+				t.Errorf("Do() code = %v, want %v, body %q", res.StatusCode, tt.wantCode, body)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("Do() body = %q, want %q", body, tt.wantBody)
+			}
+			if req.Header.Get("X-Hmac-Signature") != "" {
+				t.Error("expected request of the caller not to be modified")
+			}
+		})
+	}
+}
+
+func TestNewEd25519Transport(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(nil)
+	getPublicKey := func(context.Context, string) (ed25519.PublicKey, error) { return publicKey, nil }
+	server := httptest.NewServer(NewEd25519Middleware(getPublicKey)(http.HandlerFunc(echo)))
+	defer server.Close()
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	for name, want := range map[string]struct {
+		key  ed25519.PrivateKey
+		code int
+	}{"Signed": {privateKey, http.StatusOK}, "OtherKey": {otherKey, http.StatusUnauthorized}} {
+		t.Run(name, func(t *testing.T) {
+			client := &http.Client{Transport: NewEd25519Transport(nil, "key1", want.key)}
+			res, err := client.Get(server.URL + "/v1/users")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != want.code {
+				t.Errorf("Get() code = %v, want %v", res.StatusCode, want.code)
+			}
+		})
+	}
+}
+
+func TestNewTransport_bodyTooLarge(t *testing.T) {
+	client := &http.Client{Transport: NewTransport(nil, "key1", "secret1", WithMaxBodySize(1))}
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://127.0.0.1:0/", strings.NewReader("{}"))
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), ErrBodyTooLarge.Error()) {
+		t.Errorf("Do() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
+
+// closeRecorder records whether a body was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestNewTransport_body(t *testing.T) {
+	server := httptest.NewServer(NewMiddleware(getSecret)(http.HandlerFunc(echo)))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(nil, "key1", "secret1")}
+	for name, getBody := range map[string]bool{"GetBody": true, "Buffered": false} {
+		t.Run(name, func(t *testing.T) {
+			body := &closeRecorder{Reader: strings.NewReader("{}")}
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/v1/users", body)
+			if getBody {
+				req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("{}")), nil }
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer res.Body.Close()
+			got, _ := io.ReadAll(res.Body)
+			if res.StatusCode != http.StatusOK || string(got) != "key1:{}" {
+				t.Errorf("Do() code = %v, body %q", res.StatusCode, got)
+			}
+			if !body.closed {
+				t.Error("expected the body of the request to be closed")
+			}
+		})
+	}
+}
+
+// failingTransport fails every request with err.
+type failingTransport struct {
+	err error
+}
+
+func (f failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
+
+func TestNewTransport_baseError(t *testing.T) {
+	want := errors.New("connection refused")
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.com/", nil)
+	if _, err := NewTransport(failingTransport{want}, "key1", "secret1").RoundTrip(req); err != want { //nolint:errorlint
+		t.Errorf("RoundTrip() error = %v, want the error of the base transport %v", err, want)
+	}
+}
@@ -0,0 +1,135 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	hmacv1 "github.com/yogeshlonkar/go-grpc-hmac/proto/hmac/v1"
+)
+
+// ErrInsufficientScope is returned when the key id of a request has none of the scopes of the method.
+var ErrInsufficientScope = status.Errorf(codes.PermissionDenied, "x-hmac-key-id has no scope of the method")
+
+// GetScopes is a function that returns the scopes of a given keyId, checked against the scopes of methods declared
+// with the (hmac.v1.auth) method option. If the function returns an error, the request is rejected.
+type GetScopes func(ctx context.Context, keyId string) (scopes []string, err error)
+
+// ServiceInfoProvider provides the services registered with a server, implemented by grpc.Server.
+type ServiceInfoProvider interface {
+	GetServiceInfo() map[string]grpc.ServiceInfo
+}
+
+// UseMethodOptions reads the (hmac.v1.auth) method option of the services registered with server, e.g. a grpc.Server,
+// from the descriptors in protoregistry.GlobalFiles. Methods with required set to false are ignored, methods with
+// scopes reject key ids without one of the scopes returned by the GetScopes of WithScopes. Methods without the option
+// or descriptor require HMAC from any key id. Returns an error if methods declare scopes without WithScopes. Call it
+// after registering services and before serving requests, calling it again replaces the methods read before but not
+// those of IgnoredMethods.
+func (s *serverInterceptor) UseMethodOptions(server ServiceInfoProvider) error {
+	return s.useMethodOptions(server, protoregistry.GlobalFiles)
+}
+
+func (s *serverInterceptor) useMethodOptions(server ServiceInfoProvider, files *protoregistry.Files) error {
+	scopes := make(map[string][]string)
+	var optional []string
+	for service, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			auth, ok := methodAuth(files, protoreflect.FullName(service+"."+method.Name))
+			if !ok {
+				continue
+			}
+			fullMethod := "/" + service + "/" + method.Name
+			if auth.Required != nil && !auth.GetRequired() {
+				logger.Printf("method %s does not require HMAC", fullMethod)
+				optional = append(optional, fullMethod)
+				continue
+			}
+			if len(auth.GetScopes()) > 0 {
+				scopes[fullMethod] = auth.GetScopes()
+			}
+		}
+	}
+	if len(scopes) > 0 && s.getScopes == nil {
+		return errors.New("hmac: methods declare scopes of key ids, WithScopes is required")
+	}
+	s.optional = optional
+	s.scopes = scopes
+	return nil
+}
+
+// methodAuth returns the (hmac.v1.auth) option of the method named name in files.
+func methodAuth(files *protoregistry.Files, name protoreflect.FullName) (*hmacv1.MethodAuth, bool) {
+	desc, err := files.FindDescriptorByName(name)
+	if err != nil {
+		logger.Printf("no descriptor for method %s: %q", name, err)
+		return nil, false
+	}
+	method, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, false
+	}
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || !proto.HasExtension(opts, hmacv1.E_Auth) {
+		return nil, false
+	}
+	auth, ok := proto.GetExtension(opts, hmacv1.E_Auth).(*hmacv1.MethodAuth)
+	return auth, ok
+}
+
+// methodScopes returns a function returning the scopes of a full method name declared with the (hmac.v1.auth) option in
+// files. Only the scopes of methods found in files are cached, so requests to unknown methods do not grow the cache.
+func methodScopes(files *protoregistry.Files) func(method string) []string {
+	var cache sync.Map
+	return func(method string) []string {
+		if scopes, ok := cache.Load(method); ok {
+			return scopes.([]string) //nolint:forcetypeassert
+		}
+		name := protoreflect.FullName(strings.Replace(strings.TrimPrefix(method, "/"), "/", ".", 1))
+		if _, err := files.FindDescriptorByName(name); err != nil {
+			return nil
+		}
+		auth, _ := methodAuth(files, name)
+		scopes := auth.GetScopes()
+		cache.Store(method, scopes)
+		return scopes
+	}
+}
+
+// authorize checks the key id authenticated for a request has one of the scopes of method.
+func (s *serverInterceptor) authorize(ctx context.Context, method string) error {
+	return s.checkScopes(ctx, method, s.scopes[method])
+}
+
+// checkScopes checks the key id authenticated for a request to method has one of the required scopes.
+func (s *serverInterceptor) checkScopes(ctx context.Context, method string, required []string) error {
+	if len(required) == 0 {
+		return nil
+	}
+	keyID, ok := KeyID(ctx)
+	if !ok || s.getScopes == nil {
+		return ErrInsufficientScope
+	}
+	scopes, err := s.getScopes(ctx, keyID)
+	if err != nil {
+		logger.Printf("internal error getting scopes for keyID %s: %q", keyID, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	for _, scope := range scopes {
+		if slices.Contains(required, scope) {
+			return nil
+		}
+	}
+	logger.Printf("keyID %s has none of the scopes %v of method %s", keyID, required, method)
+	return ErrInsufficientScope
+}
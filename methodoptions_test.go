@@ -0,0 +1,208 @@
+package hmac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	hmacv1 "github.com/yogeshlonkar/go-grpc-hmac/proto/hmac/v1"
+)
+
+// authFiles returns the descriptor of the test.v1.Admin service declaring the (hmac.v1.auth) option of its methods.
+func authFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+	method := func(name string, auth *hmacv1.MethodAuth) *descriptorpb.MethodDescriptorProto {
+		opts := &descriptorpb.MethodOptions{}
+		if auth != nil {
+			proto.SetExtension(opts, hmacv1.E_Auth, auth)
+		}
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".google.protobuf.StringValue"),
+			OutputType: proto.String(".google.protobuf.StringValue"),
+			Options:    opts,
+		}
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/admin.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "hmac/v1/auth.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Admin"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Health", &hmacv1.MethodAuth{Required: proto.Bool(false)}),
+				method("Reset", &hmacv1.MethodAuth{Scopes: []string{"admin", "ops"}}),
+				method("Status", &hmacv1.MethodAuth{Required: proto.Bool(true)}),
+				method("List", nil),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("NewFile() error = %v", err)
+	}
+	files := &protoregistry.Files{}
+	if err = files.RegisterFile(fd); err != nil {
+		t.Fatalf("RegisterFile() error = %v", err)
+	}
+	return files
+}
+
+// adminServer returns a grpc.Server with the test.v1.Admin service registered.
+func adminServer() *grpc.Server {
+	server := grpc.NewServer()
+	methods := make([]grpc.MethodDesc, 0, 5)
+	for _, name := range []string{"Health", "Reset", "Status", "List", "Unknown"} {
+		methods = append(methods, grpc.MethodDesc{MethodName: name})
+	}
+	server.RegisterService(&grpc.ServiceDesc{ServiceName: "test.v1.Admin", HandlerType: (*interface{})(nil), Methods: methods}, struct{}{})
+	return server
+}
+
+func TestServerInterceptor_UseMethodOptions(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	getScopes := func(_ context.Context, keyId string) ([]string, error) {
+		switch keyId {
+		case "ops":
+			return []string{"read", "ops"}, nil
+		case "error":
+			return nil, errors.New("failed")
+		}
+		return []string{"read"}, nil
+	}
+	s := NewServerInterceptor(getSecret, WithScopes(getScopes)).(*serverInterceptor) //nolint:forcetypeassert
+	if err := s.useMethodOptions(adminServer(), authFiles(t)); err != nil {
+		t.Fatalf("useMethodOptions() error = %v", err)
+	}
+	tests := []struct {
+		method string
+		keyID  string
+		want   codes.Code
+	}{
+		{method: "/test.v1.Admin/Health", want: codes.OK},
+		{method: "/test.v1.Admin/Reset", keyID: "ops", want: codes.OK},
+		{method: "/test.v1.Admin/Reset", keyID: "reader", want: codes.PermissionDenied},
+		{method: "/test.v1.Admin/Reset", keyID: "error", want: codes.Internal},
+		{method: "/test.v1.Admin/Reset", want: codes.Unauthenticated},
+		{method: "/test.v1.Admin/Status", keyID: "reader", want: codes.OK},
+		{method: "/test.v1.Admin/Status", want: codes.Unauthenticated},
+		{method: "/test.v1.Admin/List", keyID: "reader", want: codes.OK},
+		{method: "/test.v1.Admin/List", want: codes.Unauthenticated},
+		{method: "/test.v1.Admin/Unknown", want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+"/"+tt.keyID, func(t *testing.T) {
+			ctx := context.Background()
+			message, _ := NewMessage(nil, tt.method)
+			if tt.keyID != "" {
+				ctx = metadata.NewIncomingContext(ctx, Sign(tt.keyID, "secret1", message))
+			}
+			handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil } //nolint:nilnil
+			_, err := s.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.want {
+				t.Errorf("UnaryServerInterceptor() error = %v, want code %v", err, tt.want)
+			}
+			stream := func(interface{}, grpc.ServerStream) error { return nil }
+			err = s.StreamServerInterceptor(nil, &incomingServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, stream)
+			if status.Code(err) != tt.want {
+				t.Errorf("StreamServerInterceptor() error = %v, want code %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerInterceptor_UseMethodOptions_withoutScopes(t *testing.T) {
+	s := NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil }).(*serverInterceptor) //nolint:forcetypeassert
+	if err := s.useMethodOptions(adminServer(), authFiles(t)); err == nil {
+		t.Error("useMethodOptions() expected error for scopes without WithScopes")
+	}
+	if err := s.UseMethodOptions(adminServer()); err != nil {
+		t.Errorf("UseMethodOptions() error = %v for services without descriptors in protoregistry.GlobalFiles", err)
+	}
+}
+
+func TestServerInterceptor_UseMethodOptions_again(t *testing.T) {
+	getScopes := func(context.Context, string) ([]string, error) { return nil, nil }
+	s := NewServerInterceptor(func(context.Context, string) (string, error) { return "secret1", nil }, WithScopes(getScopes)).(*serverInterceptor) //nolint:forcetypeassert
+	s.IgnoredMethods("/test.v1.Admin/Other")
+	for range 2 {
+		if err := s.useMethodOptions(adminServer(), authFiles(t)); err != nil {
+			t.Fatalf("useMethodOptions() error = %v", err)
+		}
+	}
+	if want := []string{"/test.v1.Admin/Health"}; !slices.Equal(s.optional, want) {
+		t.Errorf("useMethodOptions() optional = %v, want %v", s.optional, want)
+	}
+	if err := s.UseMethodOptions(adminServer()); err != nil {
+		t.Fatalf("UseMethodOptions() error = %v", err)
+	}
+	if s.ignored("/test.v1.Admin/Health") || !s.ignored("/test.v1.Admin/Other") {
+		t.Errorf("UseMethodOptions() did not replace the methods read before, ignored = %v, optional = %v", s.ignore, s.optional)
+	}
+}
+
+// methodStream is a grpc.ServerTransportStream of a call to method.
+type methodStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s methodStream) Method() string {
+	return s.method
+}
+
+func TestAuthFunc_scopes(t *testing.T) {
+	getSecret := func(context.Context, string) (string, error) { return "secret1", nil }
+	getScopes := func(_ context.Context, keyId string) ([]string, error) {
+		if keyId == "ops" {
+			return []string{"ops"}, nil
+		}
+		return nil, nil
+	}
+	var audited error
+	audit := func(_ context.Context, _ string, err error) { audited = err }
+	files := authFiles(t)
+	tests := []struct {
+		name   string
+		keyID  string
+		method string
+		opts   []Option
+		want   codes.Code
+	}{
+		{name: "Scope", keyID: "ops", method: "/test.v1.Admin/Reset", opts: []Option{WithScopes(getScopes)}},
+		{name: "InsufficientScope", keyID: "key1", method: "/test.v1.Admin/Reset", opts: []Option{WithScopes(getScopes)}, want: codes.PermissionDenied},
+		{name: "WithoutScopes", keyID: "ops", method: "/test.v1.Admin/Reset", want: codes.PermissionDenied},
+		{name: "MethodWithoutScopes", keyID: "key1", method: "/test.v1.Admin/List", opts: []Option{WithScopes(getScopes)}},
+		{name: "UnknownMethod", keyID: "key1", method: "/test.v1.Admin/Unknown", opts: []Option{WithScopes(getScopes)}},
+		{name: "DryRun", keyID: "key1", method: "/test.v1.Admin/Reset", opts: []Option{WithScopes(getScopes), WithDryRun(audit, nil)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audited = nil
+			message, _ := NewMessage(nil, tt.method)
+			ctx := metadata.NewIncomingContext(context.Background(), Sign(tt.keyID, "secret1", message))
+			ctx = grpc.NewContextWithServerTransportStream(ctx, methodStream{method: tt.method})
+			auth := authFunc(getSecret, files, tt.opts...)
+			// twice to use the cached scopes
+			for range 2 {
+				if _, err := auth(ctx); status.Code(err) != tt.want {
+					t.Errorf("authFunc() error = %v, want code %v", err, tt.want)
+				}
+			}
+			if tt.name == "DryRun" && !errors.Is(audited, ErrInsufficientScope) {
+				t.Errorf("audited error = %v, want %v", audited, ErrInsufficientScope)
+			}
+		})
+	}
+}
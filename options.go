@@ -0,0 +1,176 @@
+package hmac
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the interceptors.
+// Defaults to time.Now, tests can provide a fake implementation to control time based checks.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// Option configures client and server interceptors.
+// Unless documented otherwise the same options must be passed on both sides for signatures to match.
+type Option func(*options)
+
+type options struct {
+	clock   Clock
+	maxSkew time.Duration
+	expiry  time.Duration
+	expires bool
+	derived *derivedKeys
+	// authority binds the :authority to the signature, authorities are the expected values.
+	authority   bool
+	authorities []string
+	// channelBinding signs TLS exporter keying material of the connection.
+	channelBinding bool
+	// contentDigest references proto requests by digest in the message.
+	contentDigest bool
+	// forwarding trusts key ids forwarded by a HTTP gateway.
+	forwarding *forwarding
+	// fallback authenticates requests without or in addition to HMAC.
+	fallback *fallback
+	// getScopes returns the scopes of key ids for methods with scopes.
+	getScopes GetScopes
+	// dryRun lets requests failing authentication or authorization through.
+	dryRun *dryRun
+}
+
+// WithClock sets the Clock used for timestamps and time based checks.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithTimestamp includes the current time as x-hmac-timestamp metadata in the signature.
+// The server rejects requests with a timestamp differing more than maxSkew from its own clock.
+func WithTimestamp(maxSkew time.Duration) Option {
+	return func(o *options) {
+		o.maxSkew = maxSkew
+	}
+}
+
+// WithExpiry includes an expiry time as x-hmac-expires metadata in the signature. The client sets it to the deadline of
+// the call context or the current time plus ttl, whichever is earlier, if ttl is not positive only the deadline is used.
+// The server rejects requests without x-hmac-expires if ttl is positive, or expiring more than ttl plus the timestamp
+// skew in the future. Requests with x-hmac-expires in the past are always rejected, even without this option.
+func WithExpiry(ttl time.Duration) Option {
+	return func(o *options) {
+		o.expiry = ttl
+		o.expires = true
+	}
+}
+
+// WithAuthority includes the :authority of the call in the signature, so a signature for one environment or service
+// cannot be replayed against another using the same key id. The client signs the first authority if any, otherwise the
+// authority of a grpc.CallAuthority call option or the one derived from the target of the connection. The server reads
+// :authority from the incoming metadata and, if any authorities are given, rejects other authorities.
+func WithAuthority(authorities ...string) Option {
+	return func(o *options) {
+		o.authority = true
+		o.authorities = authorities
+	}
+}
+
+// WithChannelBinding includes keying material exported from the TLS session of the connection (RFC 5705) in the
+// signature, so a signed request cannot be forwarded over another connection by an intermediary terminating TLS.
+// Requires TLS 1.3, or TLS 1.2 with extended master secret, on both sides and the interceptors, it cannot be used with
+// Sign. The client signs the request when it is sent on a connection, calls on insecure connections fail.
+func WithChannelBinding() Option {
+	return func(o *options) {
+		o.channelBinding = true
+	}
+}
+
+// WithContentDigest references proto requests of unary calls in the signed message by the SHA-256 digest of their
+// deterministic protobuf encoding instead of their JSON encoding, see ContentDigest. The client sends the digest as
+// x-hmac-content-digest, the server computes it from the received request. This avoids building the JSON encoding of
+// large requests, other requests are encoded as by NewMessage.
+func WithContentDigest() Option {
+	return func(o *options) {
+		o.contentDigest = true
+	}
+}
+
+// WithDerivedKeys signs requests with a key derived from the secret for the current UTC date and the called method,
+// see DeriveKey, instead of the secret itself. A leaked signature key is only valid for one method on one day.
+// The date is sent as x-hmac-date metadata, the server accepts the previous, current and next date of its own clock.
+// Derived keys are cached until their date is no longer accepted.
+func WithDerivedKeys() Option {
+	return func(o *options) {
+		o.derived = newDerivedKeys()
+	}
+}
+
+// WithForwardedKeyID trusts the key id forwarded by a HTTP gateway with ForwardKeyID, signed with secret at most maxAge
+// ago, instead of verifying the request again. Requests without forwarded metadata are verified as usual, requests with
+// an invalid forwarded key id are rejected. Only applies to server interceptors, handlers read the trusted key id from
+// x-hmac-forwarded-key-id metadata. The forwarded metadata is bound to the method but not to the request, the connection
+// between the gateway and the server must not be observable by untrusted parties. An empty secret is no secret, forwarded
+// key ids are not trusted then.
+func WithForwardedKeyID(secret string, maxAge time.Duration) Option {
+	return func(o *options) {
+		if secret == "" {
+			logger.Printf("no secret for forwarded key ids, forwarding is disabled")
+			o.forwarding = nil
+			return
+		}
+		o.forwarding = &forwarding{newStringSecret(secret), maxAge}
+	}
+}
+
+// WithFallback authenticates requests with authenticator in addition to HMAC. With AnyOf requests without any x-hmac
+// metadata are authenticated by authenticator only, e.g. clients migrating to bearer tokens, requests with x-hmac
+// metadata are verified as usual. With AllOf requests must be authenticated by both. Only applies to server
+// interceptors and AuthFunc, handlers read the authenticated key id and subject with IdentityFromContext. Requests
+// rejected by authenticator with a gRPC status error fail with that error, other failures with ErrUnauthorized.
+func WithFallback(authenticator Authenticator, combine Combine) Option {
+	return func(o *options) {
+		o.fallback = &fallback{authenticator, combine}
+	}
+}
+
+// WithScopes sets the GetScopes of key ids checked against the scopes of methods declared with the (hmac.v1.auth)
+// method option, see ServerInterceptor.UseMethodOptions. Requests to methods with scopes are rejected with
+// ErrInsufficientScope unless the key id has one of the scopes. Only applies to server interceptors.
+func WithScopes(getScopes GetScopes) Option {
+	return func(o *options) {
+		o.getScopes = getScopes
+	}
+}
+
+// WithDryRun verifies requests as usual but lets requests failing authentication or authorization through to the
+// handler, reporting the error they would have been rejected with to audit, if not nil, and the logger. Requests for
+// which enforce returns true are rejected as usual, e.g. EnforceMethods or EnforcePercent to roll out enforcement
+// gradually, if nil no request is rejected. Requests let through have no Identity. Only applies to server interceptors
+// and AuthFunc.
+func WithDryRun(audit AuditFunc, enforce Enforce) Option {
+	return func(o *options) {
+		o.dryRun = &dryRun{audit, enforce}
+	}
+}
+
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o *options) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock.Now()
+}
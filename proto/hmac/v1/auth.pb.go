@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: hmac/v1/auth.proto
+
+package hmacv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MethodAuth declares the HMAC authentication of a method, read by the UseMethodOptions of the server interceptor.
+type MethodAuth struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Required HMAC authentication, methods without the option require it.
+	Required *bool `protobuf:"varint,1,opt,name=required,proto3,oneof" json:"required,omitempty"`
+	// Scopes of which a key id needs one to call the method, any key id is allowed if empty.
+	Scopes        []string `protobuf:"bytes,2,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MethodAuth) Reset() {
+	*x = MethodAuth{}
+	mi := &file_hmac_v1_auth_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MethodAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MethodAuth) ProtoMessage() {}
+
+func (x *MethodAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_hmac_v1_auth_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MethodAuth.ProtoReflect.Descriptor instead.
+func (*MethodAuth) Descriptor() ([]byte, []int) {
+	return file_hmac_v1_auth_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MethodAuth) GetRequired() bool {
+	if x != nil && x.Required != nil {
+		return *x.Required
+	}
+	return false
+}
+
+func (x *MethodAuth) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+var file_hmac_v1_auth_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*MethodAuth)(nil),
+		Field:         51234,
+		Name:          "hmac.v1.auth",
+		Tag:           "bytes,51234,opt,name=auth",
+		Filename:      "hmac/v1/auth.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// Auth of the method, e.g. option (hmac.v1.auth) = { required: false };
+	// The field number is stable, other extensions of MethodOptions in the same descriptor pool must not use it.
+	//
+	// optional hmac.v1.MethodAuth auth = 51234;
+	E_Auth = &file_hmac_v1_auth_proto_extTypes[0]
+)
+
+var File_hmac_v1_auth_proto protoreflect.FileDescriptor
+
+const file_hmac_v1_auth_proto_rawDesc = "" +
+	"\n" +
+	"\x12hmac/v1/auth.proto\x12\ahmac.v1\x1a google/protobuf/descriptor.proto\"R\n" +
+	"\n" +
+	"MethodAuth\x12\x1f\n" +
+	"\brequired\x18\x01 \x01(\bH\x00R\brequired\x88\x01\x01\x12\x16\n" +
+	"\x06scopes\x18\x02 \x03(\tR\x06scopesB\v\n" +
+	"\t_required:I\n" +
+	"\x04auth\x12\x1e.google.protobuf.MethodOptions\x18\xa2\x90\x03 \x01(\v2\x13.hmac.v1.MethodAuthR\x04authB;Z9github.com/yogeshlonkar/go-grpc-hmac/proto/hmac/v1;hmacv1b\x06proto3"
+
+var (
+	file_hmac_v1_auth_proto_rawDescOnce sync.Once
+	file_hmac_v1_auth_proto_rawDescData []byte
+)
+
+func file_hmac_v1_auth_proto_rawDescGZIP() []byte {
+	file_hmac_v1_auth_proto_rawDescOnce.Do(func() {
+		file_hmac_v1_auth_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_hmac_v1_auth_proto_rawDesc), len(file_hmac_v1_auth_proto_rawDesc)))
+	})
+	return file_hmac_v1_auth_proto_rawDescData
+}
+
+var file_hmac_v1_auth_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_hmac_v1_auth_proto_goTypes = []any{
+	(*MethodAuth)(nil),                 // 0: hmac.v1.MethodAuth
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_hmac_v1_auth_proto_depIdxs = []int32{
+	1, // 0: hmac.v1.auth:extendee -> google.protobuf.MethodOptions
+	0, // 1: hmac.v1.auth:type_name -> hmac.v1.MethodAuth
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_hmac_v1_auth_proto_init() }
+func file_hmac_v1_auth_proto_init() {
+	if File_hmac_v1_auth_proto != nil {
+		return
+	}
+	file_hmac_v1_auth_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hmac_v1_auth_proto_rawDesc), len(file_hmac_v1_auth_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_hmac_v1_auth_proto_goTypes,
+		DependencyIndexes: file_hmac_v1_auth_proto_depIdxs,
+		MessageInfos:      file_hmac_v1_auth_proto_msgTypes,
+		ExtensionInfos:    file_hmac_v1_auth_proto_extTypes,
+	}.Build()
+	File_hmac_v1_auth_proto = out.File
+	file_hmac_v1_auth_proto_goTypes = nil
+	file_hmac_v1_auth_proto_depIdxs = nil
+}
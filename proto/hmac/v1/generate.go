@@ -0,0 +1,5 @@
+// Package hmacv1 holds the (hmac.v1.auth) method option declaring the HMAC authentication of methods, read by the
+// UseMethodOptions of the server interceptor.
+package hmacv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative hmac/v1/auth.proto
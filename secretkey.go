@@ -0,0 +1,268 @@
+package hmac
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding"
+	"encoding/base64"
+	"hash"
+	"sync"
+)
+
+const (
+	ipad = 0x36
+	opad = 0x5c
+)
+
+// Secret is a HMAC secret key held as bytes that can be wiped from memory.
+// The key is not retained, instead the HMAC inner and outer hash states keyed with it are precomputed, so signing does
+// not re-key HMAC on every call. Signing restores the states into pooled hashes and writes the message to them in parts,
+// it does not allocate beyond the returned signature. A Secret is safe for concurrent use.
+type Secret struct {
+	mu           sync.RWMutex
+	inner, outer []byte
+}
+
+// GetSecretKey is a function that returns the Secret for a given keyId.
+// Returns nil in case the keyId is not found instead of an error.
+// If the function returns an error, the request is rejected.
+// The returned Secret is not wiped by the interceptor, so it can be cached and reused by the function.
+type GetSecretKey func(ctx context.Context, keyId string) (secret *Secret, err error)
+
+// NewSecret returns a Secret for key. The Secret does not reference key, callers should zero key once it is no longer
+// needed, for example using WipeBytes.
+func NewSecret(key []byte) *Secret {
+	block := make([]byte, sha512.BlockSize)
+	defer WipeBytes(block)
+	if len(key) > sha512.BlockSize {
+		sum := sha512.Sum512_256(key)
+		copy(block, sum[:])
+		WipeBytes(sum[:])
+	} else {
+		copy(block, key)
+	}
+	s := &Secret{}
+	s.inner = keyedState(block, ipad)
+	s.outer = keyedState(block, opad)
+	return s
+}
+
+// newStringSecret returns a Secret for secret, zeroing the copy of secret made to create it.
+func newStringSecret(secret string) *Secret {
+	key := []byte(secret)
+	defer WipeBytes(key)
+	return NewSecret(key)
+}
+
+// keyedState returns the marshaled state of a hash after writing block XOR pad.
+func keyedState(block []byte, pad byte) []byte {
+	padded := make([]byte, len(block))
+	defer WipeBytes(padded)
+	for i, b := range block {
+		padded[i] = b ^ pad
+	}
+	h := sha512.New512_256()
+	h.Write(padded)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	h.Reset()
+	return state
+}
+
+// Sign generates a HMAC signature of message and returns it base64 encoded, it returns nil once the Secret is wiped.
+func (s *Secret) Sign(message string) []byte {
+	var encoded [signatureLength]byte
+	if !s.encodedSum(&encoded, messageOf(message)) {
+		return nil
+	}
+	return append([]byte(nil), encoded[:]...)
+}
+
+// signature is like Sign returning a string.
+func (s *Secret) signature(message canonical) string {
+	var encoded [signatureLength]byte
+	if !s.encodedSum(&encoded, message) {
+		return ""
+	}
+	return string(encoded[:])
+}
+
+func (s *Secret) verify(message canonical, signature string) bool {
+	var encoded, received [signatureLength]byte
+	if len(signature) != signatureLength || !s.encodedSum(&encoded, message) {
+		return false
+	}
+	copy(received[:], signature)
+	return hmac.Equal(received[:], encoded[:])
+}
+
+// encodedSum writes the base64 encoded HMAC of message to encoded, it returns false once the Secret is wiped.
+func (s *Secret) encodedSum(encoded *[signatureLength]byte, message canonical) bool {
+	var raw [sha512.Size256]byte
+	defer WipeBytes(raw[:])
+	if s.appendSum(raw[:0], &message) == nil {
+		return false
+	}
+	base64.StdEncoding.Encode(encoded[:], raw[:])
+	return true
+}
+
+// sum returns the raw HMAC of message, or nil once the Secret is wiped.
+func (s *Secret) sum(message string) []byte {
+	m := messageOf(message)
+	return s.appendSum(make([]byte, 0, sha512.Size256), &m)
+}
+
+// appendSum appends the raw HMAC of message to dst, it returns nil once the Secret is wiped.
+// The hashes are taken from a pool and keyed by restoring the precomputed states, the parts of the message are written
+// to the inner hash through a fixed size buffer. The hashes are reset before being returned to the pool so no keyed
+// state outlives the call.
+func (s *Secret) appendSum(dst []byte, message *canonical) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.inner == nil {
+		return nil
+	}
+	m := macs.Get().(*mac) //nolint:forcetypeassert
+	defer m.release()
+	restore(m.inner, s.inner)
+	m.inner.Write(message.head)
+	m.write(message.message)
+	for _, f := range message.fields[:message.n] {
+		m.write(";")
+		m.write(f.key)
+		m.write("=")
+		m.write(f.value)
+	}
+	m.flush()
+	sum := m.inner.Sum(m.sum[:0])
+	restore(m.outer, s.outer)
+	m.outer.Write(sum)
+	return append(dst, m.outer.Sum(m.sum[:0])...)
+}
+
+// signatureLength is the length of base64 encoded signatures, base64.StdEncoding.EncodedLen(sha512.Size256).
+const signatureLength = (sha512.Size256 + 2) / 3 * 4 //nolint:mnd
+
+// mac holds hashes and buffers reused between signatures.
+type mac struct {
+	inner, outer hash.Hash
+	// buf holds n bytes of the message not yet written to the inner hash.
+	buf [sha512.BlockSize]byte
+	n   int
+	sum [sha512.Size256]byte
+}
+
+var macs = sync.Pool{New: func() interface{} {
+	return &mac{inner: sha512.New512_256(), outer: sha512.New512_256()}
+}}
+
+// write adds s to the buffer, writing it to the inner hash whenever it is full.
+func (m *mac) write(s string) {
+	for len(s) > 0 {
+		n := copy(m.buf[m.n:], s)
+		m.n += n
+		s = s[n:]
+		if m.n == len(m.buf) {
+			m.flush()
+		}
+	}
+}
+
+// flush writes the buffer to the inner hash.
+func (m *mac) flush() {
+	m.inner.Write(m.buf[:m.n])
+	m.n = 0
+}
+
+func (m *mac) release() {
+	m.inner.Reset()
+	m.outer.Reset()
+	WipeBytes(m.buf[:])
+	WipeBytes(m.sum[:])
+	m.n = 0
+	macs.Put(m)
+}
+
+// restore sets h to a state marshaled by the same hash.
+func restore(h hash.Hash, state []byte) {
+	// the state was marshaled by the same hash, unmarshaling cannot fail
+	_ = h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state)
+}
+
+// Wipe zeroes the keyed hash states, after which Sign returns nil.
+func (s *Secret) Wipe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	WipeBytes(s.inner)
+	WipeBytes(s.outer)
+	s.inner, s.outer = nil, nil
+}
+
+// WipeBytes zeroes b.
+func WipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// verifiers adapts getSecretKey to return the Secret as verifier.
+func (getSecretKey GetSecretKey) verifiers() getVerifier {
+	return func(ctx context.Context, keyId string) (verifier, error) {
+		secret, err := getSecretKey(ctx, keyId)
+		if secret == nil {
+			return nil, err
+		}
+		return secret, err
+	}
+}
+
+// Keyed returns a GetSecretKey caching the Secret of each known keyId until getSecret returns a different secret.
+// Secrets are not retained to detect changes, the cache compares their HMAC under a random key instead. The previous
+// Secret of a keyId is dropped once its secret changes but not wiped, concurrent requests may still be verifying with
+// it, it is left to the garbage collector.
+func (getSecret GetSecret) Keyed() GetSecretKey {
+	type entry struct {
+		digest [sha512.Size256]byte
+		key    *Secret
+	}
+	fingerprint := randomSecret()
+	var (
+		cache sync.Map
+		mu    sync.Mutex
+	)
+	return func(ctx context.Context, keyId string) (*Secret, error) {
+		secret, err := getSecret(ctx, keyId)
+		if err != nil || secret == "" {
+			return nil, err
+		}
+		var digest [sha512.Size256]byte
+		message := messageOf(secret)
+		fingerprint.appendSum(digest[:0], &message)
+		if cached, ok := cache.Load(keyId); ok && hmac.Equal(cached.(*entry).digest[:], digest[:]) { //nolint:forcetypeassert
+			return cached.(*entry).key, nil //nolint:forcetypeassert
+		}
+		// serialize replacing Secrets, so concurrent calls after a change share one new Secret
+		mu.Lock()
+		defer mu.Unlock()
+		if cached, ok := cache.Load(keyId); ok {
+			previous := cached.(*entry) //nolint:forcetypeassert
+			if hmac.Equal(previous.digest[:], digest[:]) {
+				return previous.key, nil
+			}
+		}
+		key := newStringSecret(secret)
+		cache.Store(keyId, &entry{digest, key})
+		return key, nil
+	}
+}
+
+// randomSecret returns a Secret for a random key.
+func randomSecret() *Secret {
+	key := make([]byte, sha512.Size256)
+	defer WipeBytes(key)
+	// rand.Read only fails on platforms without a secure random source, where crypto/rand panics in Go 1.24
+	_, _ = rand.Read(key)
+	return NewSecret(key)
+}
@@ -0,0 +1,154 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSecret_Sign(t *testing.T) {
+	for _, size := range []int{0, 1, 32, sha512.BlockSize - 1, sha512.BlockSize, sha512.BlockSize + 1, 300} {
+		key := bytes.Repeat([]byte{'k'}, size)
+		mac := hmac.New(sha512.New512_256, key)
+		mac.Write([]byte("message"))
+		want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		secret := NewSecret(key)
+		if got := string(secret.Sign("message")); got != want {
+			t.Errorf("Sign() with %d byte key got = %v, want %v", size, got, want)
+		}
+		if got := string(secret.Sign("message")); got != want {
+			t.Errorf("Sign() with %d byte key got = %v on second call, want %v", size, got, want)
+		}
+	}
+}
+
+func TestSecret_canonical(t *testing.T) {
+	secret := NewSecret([]byte("secret1"))
+	long := strings.Repeat("gopher", 100)
+	tests := []struct {
+		name    string
+		message canonical
+		want    string
+	}{
+		{name: "Message", message: messageOf("method=/svc/Method"), want: "method=/svc/Method"},
+		{name: "Head", message: canonical{head: []byte(`request={"name":"gopher"};method=`), message: "/svc/Method"}, want: `request={"name":"gopher"};method=/svc/Method`},
+		{name: "LongHead", message: canonical{head: []byte("request=" + long + ";method="), message: "/svc/Method"}, want: "request=" + long + ";method=/svc/Method"},
+		{
+			name:    "Fields",
+			message: canonical{message: "method=/svc/Method", fields: [maxFields]field{{"timestamp", "1700000000"}, {"authority", long}}, n: 2},
+			want:    "method=/svc/Method;timestamp=1700000000;authority=" + long,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.message.String(); got != tt.want {
+				t.Errorf("String() got = %v, want %v", got, tt.want)
+			}
+			if got, want := secret.signature(tt.message), String("secret1", tt.want); got != want {
+				t.Errorf("signature() got = %v, want %v", got, want)
+			}
+			if got := tt.message.method(); got != "/svc/Method" {
+				t.Errorf("method() got = %v, want /svc/Method", got)
+			}
+		})
+	}
+}
+
+func TestSecret_concurrent(t *testing.T) {
+	secrets := []*Secret{NewSecret([]byte("secret1")), NewSecret([]byte("secret2"))}
+	want := []string{String("secret1", "message"), String("secret2", "message")}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got := secrets[i%2].signature(messageOf("message")); got != want[i%2] {
+					t.Errorf("signature() got = %v, want %v", got, want[i%2])
+					return
+				}
+				if !secrets[i%2].verify(messageOf("message"), want[i%2]) || secrets[i%2].verify(messageOf("message"), want[(i+1)%2]) {
+					t.Errorf("verify() did not match the signature of its secret")
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSecret_Wipe(t *testing.T) {
+	key := []byte("secret")
+	secret := NewSecret(key)
+	WipeBytes(key)
+	if !bytes.Equal(key, make([]byte, len(key))) {
+		t.Errorf("WipeBytes() expected key to be zeroed")
+	}
+	if got := string(secret.Sign("message")); got != String("secret", "message") {
+		t.Errorf("Sign() got = %v after wiping key, want %v", got, String("secret", "message"))
+	}
+	inner := secret.inner
+	secret.Wipe()
+	if !bytes.Equal(inner, make([]byte, len(inner))) {
+		t.Errorf("Wipe() expected keyed state to be zeroed")
+	}
+	if got := secret.Sign("message"); got != nil {
+		t.Errorf("Sign() got = %v after Wipe, want nil", got)
+	}
+}
+
+func TestGetSecret_Keyed(t *testing.T) {
+	secrets := map[string]string{"key1": "secret1"}
+	getSecretKey := GetSecret(func(_ context.Context, keyId string) (string, error) {
+		if keyId == "error" {
+			return "", errors.New("failed")
+		}
+		return secrets[keyId], nil
+	}).Keyed()
+	first, _ := getSecretKey(context.Background(), "key1")
+	second, _ := getSecretKey(context.Background(), "key1")
+	if first == nil || first != second {
+		t.Errorf("Keyed() expected Secret to be cached")
+	}
+	secrets["key1"] = "rotated1"
+	rotated, _ := getSecretKey(context.Background(), "key1")
+	if rotated == first || string(rotated.Sign("message")) != String("rotated1", "message") {
+		t.Errorf("Keyed() expected new Secret after rotation")
+	}
+	if string(first.Sign("message")) != String("secret1", "message") {
+		t.Errorf("Keyed() expected previous Secret to remain usable by concurrent requests after rotation")
+	}
+	if again, _ := getSecretKey(context.Background(), "key1"); again != rotated {
+		t.Errorf("Keyed() expected rotated Secret to be cached")
+	}
+	if unknown, err := getSecretKey(context.Background(), "unknown"); unknown != nil || err != nil {
+		t.Errorf("Keyed() got = %v, %v for unknown key id, want nil", unknown, err)
+	}
+	if _, err := getSecretKey(context.Background(), "error"); err == nil {
+		t.Errorf("Keyed() expected error")
+	}
+}
+
+func TestSignSecretVerifySecret(t *testing.T) {
+	secret := NewSecret([]byte("secret"))
+	getSecretKey := func(_ context.Context, keyId string) (*Secret, error) {
+		if keyId == "key-id" {
+			return secret, nil
+		}
+		return nil, nil
+	}
+	md := SignSecret("key-id", secret, "plain-text")
+	if err := VerifySecret(context.Background(), md, "plain-text", getSecretKey); err != nil {
+		t.Errorf("VerifySecret() error = %v", err)
+	}
+	md.Set("x-hmac-key-id", "other")
+	if err := VerifySecret(context.Background(), md, "plain-text", getSecretKey); !errors.Is(err, ErrInvalidHmacKeyID) {
+		t.Errorf("VerifySecret() error = %v, want %v", err, ErrInvalidHmacKeyID)
+	}
+}
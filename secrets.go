@@ -0,0 +1,327 @@
+package hmac
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileCheckInterval is how often FileSecrets and DirectorySecrets check for changes.
+var fileCheckInterval = time.Second
+
+// reloadCheck limits checks for changes to once per fileCheckInterval without locking the secrets.
+type reloadCheck struct {
+	// checked is the UnixNano time of the last check.
+	checked atomic.Int64
+}
+
+// due reports whether a check is due, only one of concurrent callers gets true.
+func (c *reloadCheck) due() bool {
+	last := c.checked.Load()
+	now := time.Now().UnixNano()
+	if time.Duration(now-last) < fileCheckInterval {
+		return false
+	}
+	return c.checked.CompareAndSwap(last, now)
+}
+
+// reset marks the time of a load as the last check.
+func (c *reloadCheck) reset() {
+	c.checked.Store(time.Now().UnixNano())
+}
+
+// StaticSecrets returns a GetSecret looking up secrets by keyId in a copy of secrets.
+func StaticSecrets(secrets map[string]string) GetSecret {
+	copied := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		copied[k] = v
+	}
+	return func(_ context.Context, keyId string) (string, error) {
+		return copied[keyId], nil
+	}
+}
+
+// EnvSecrets returns a GetSecret reading the secret from the environment variable prefix + keyId, with keyId
+// converted to upper case and characters other than letters and digits replaced by underscores.
+// For example with prefix "HMAC_SECRET_" the secret of key id "key-one" is read from HMAC_SECRET_KEY_ONE.
+func EnvSecrets(prefix string) GetSecret {
+	return func(_ context.Context, keyId string) (string, error) {
+		return os.Getenv(prefix + envName(keyId)), nil
+	}
+}
+
+func envName(keyId string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, keyId)
+}
+
+// FileSecrets returns a GetSecret reading key id to secret mappings from path.
+// The format is chosen by extension: .json and .yaml or .yml for a flat object, .env for KEY=VALUE lines.
+// The file is reloaded when its modification time or size changes, if reloading fails the previous secrets are kept.
+func FileSecrets(path string) (GetSecret, error) {
+	parse, err := secretsParser(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &fileSecrets{path: path, parse: parse}
+	if err = f.load(); err != nil {
+		return nil, err
+	}
+	return f.get, nil
+}
+
+type fileSecrets struct {
+	path    string
+	parse   func(data []byte) (map[string]string, error)
+	mu      sync.RWMutex
+	secrets map[string]string
+	modTime time.Time
+	size    int64
+	check   reloadCheck
+}
+
+func (f *fileSecrets) get(_ context.Context, keyId string) (string, error) {
+	f.reload()
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.secrets[keyId], nil
+}
+
+// reload the file if it changed since the last check, at most once per fileCheckInterval. Only a due check takes the
+// write lock, other calls read the secrets concurrently.
+func (f *fileSecrets) reload() {
+	if !f.check.due() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		logger.Printf("failed to check secrets file %s: %q", f.path, err)
+		return
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return
+	}
+	if err = f.loadLocked(); err != nil {
+		logger.Printf("failed to reload secrets file %s, keeping previous secrets: %q", f.path, err)
+	}
+}
+
+func (f *fileSecrets) load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.check.reset()
+	return f.loadLocked()
+}
+
+func (f *fileSecrets) loadLocked() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	secrets, err := f.parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse secrets file %s: %w", f.path, err)
+	}
+	f.secrets, f.modTime, f.size = secrets, info.ModTime(), info.Size()
+	logger.Printf("loaded %d secrets from %s", len(secrets), f.path)
+	return nil
+}
+
+func secretsParser(path string) (func(data []byte) (map[string]string, error), error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return parseJSONSecrets, nil
+	case ".yaml", ".yml":
+		return parseYAMLSecrets, nil
+	case ".env":
+		return parseEnvSecrets, nil
+	default:
+		return nil, fmt.Errorf("unsupported secrets file %s, expected .json, .yaml, .yml or .env extension", path)
+	}
+}
+
+func parseJSONSecrets(data []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func parseYAMLSecrets(data []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// parseEnvSecrets parses KEY=VALUE lines, ignoring empty lines, # comments and an export prefix.
+// Values can be quoted with single or double quotes, double quoted values are unquoted as Go strings.
+func parseEnvSecrets(data []byte) (map[string]string, error) {
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value, err := unquoteEnv(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		secrets[strings.TrimSpace(key)] = value
+	}
+	return secrets, scanner.Err()
+}
+
+func unquoteEnv(value string) (string, error) {
+	const quoted = 2
+	switch {
+	case len(value) >= quoted && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= quoted && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	default:
+		return value, nil
+	}
+}
+
+// kubernetesDataDir is the symlink Kubernetes atomically swaps when updating a projected secret volume.
+const kubernetesDataDir = "..data"
+
+// DirectorySecrets returns a GetSecret reading secrets from a directory containing one file per key id, as created by
+// mounting a Kubernetes secret volume. File names are key ids, trailing newlines are trimmed from the contents and
+// hidden files are ignored. The directory is reloaded when the ..data symlink changes, or when the modification time of
+// the directory changes if there is no ..data symlink. If reloading fails the previous secrets are kept.
+func DirectorySecrets(path string) (GetSecret, error) {
+	d := &dirSecrets{path: path}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return d.get, nil
+}
+
+type dirSecrets struct {
+	path    string
+	mu      sync.RWMutex
+	secrets map[string]string
+	version string
+	check   reloadCheck
+}
+
+func (d *dirSecrets) get(_ context.Context, keyId string) (string, error) {
+	d.reload()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.secrets[keyId], nil
+}
+
+// reload the directory if it changed since the last check, at most once per fileCheckInterval. Only a due check takes
+// the write lock, other calls read the secrets concurrently.
+func (d *dirSecrets) reload() {
+	if !d.check.due() {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	version, err := d.currentVersion()
+	if err != nil {
+		logger.Printf("failed to check secrets directory %s: %q", d.path, err)
+		return
+	}
+	if version == d.version {
+		return
+	}
+	if err = d.loadLocked(); err != nil {
+		logger.Printf("failed to reload secrets directory %s, keeping previous secrets: %q", d.path, err)
+	}
+}
+
+// currentVersion returns the target of the ..data symlink or the modification time of the directory.
+func (d *dirSecrets) currentVersion() (string, error) {
+	target, err := os.Readlink(filepath.Join(d.path, kubernetesDataDir))
+	if err == nil {
+		return target, nil
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return "", err
+	}
+	return info.ModTime().String(), nil
+}
+
+func (d *dirSecrets) load() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.check.reset()
+	return d.loadLocked()
+}
+
+func (d *dirSecrets) loadLocked() error {
+	version, err := d.currentVersion()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return err
+	}
+	secrets := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(d.path, entry.Name())
+		// Stat follows the symlinks Kubernetes creates for each key, dangling symlinks are skipped
+		info, err := os.Stat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		secrets[entry.Name()] = strings.TrimRight(string(data), "\r\n")
+	}
+	d.secrets, d.version = secrets, version
+	logger.Printf("loaded %d secrets from %s", len(secrets), d.path)
+	return nil
+}
@@ -0,0 +1,216 @@
+package hmac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaticSecrets(t *testing.T) {
+	secrets := map[string]string{"key1": "secret1"}
+	getSecret := StaticSecrets(secrets)
+	secrets["key2"] = "secret2"
+	tests := map[string]string{"key1": "secret1", "key2": "", "unknown": ""}
+	for keyID, want := range tests {
+		got, err := getSecret(context.Background(), keyID)
+		if err != nil || got != want {
+			t.Errorf("StaticSecrets()(%q) got = %q, %v, want %q", keyID, got, err, want)
+		}
+	}
+}
+
+func TestEnvSecrets(t *testing.T) {
+	t.Setenv("HMAC_SECRET_KEY_ONE", "secret1")
+	t.Setenv("HMAC_SECRET_KEY2", "secret2")
+	getSecret := EnvSecrets("HMAC_SECRET_")
+	tests := map[string]string{"key-one": "secret1", "Key2": "secret2", "key.one": "secret1", "unknown": ""}
+	for keyID, want := range tests {
+		got, err := getSecret(context.Background(), keyID)
+		if err != nil || got != want {
+			t.Errorf("EnvSecrets()(%q) got = %q, %v, want %q", keyID, got, err, want)
+		}
+	}
+}
+
+func TestFileSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"secrets.json", `{"key1": "secret1", "key2": "secret=2"}`},
+		{"secrets.yaml", "# comment\nkey1: secret1\nkey2: \"secret=2\"\n"},
+		{"secrets.yml", "key1: secret1\nkey2: secret=2\n"},
+		{"secrets.env", "# comment\n\nkey1=secret1\nexport key2 = \"secret=2\"\n"},
+		{"quoted.env", "key1='secret1'\nkey2=secret=2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			writeFile(t, path, tt.content)
+			getSecret, err := FileSecrets(path)
+			if err != nil {
+				t.Fatalf("FileSecrets() error = %v", err)
+			}
+			want := map[string]string{"key1": "secret1", "key2": "secret=2", "unknown": ""}
+			for keyID, secret := range want {
+				if got, _ := getSecret(context.Background(), keyID); got != secret {
+					t.Errorf("FileSecrets()(%q) got = %q, want %q", keyID, got, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSecrets_errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"secrets.txt", "key1=secret1"},
+		{"invalid.json", `{"key1": 1}`},
+		{"invalid.yaml", "key1: [secret1]"},
+		{"invalid.env", "key1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			writeFile(t, path, tt.content)
+			if _, err := FileSecrets(path); err == nil {
+				t.Errorf("FileSecrets() expected error")
+			}
+		})
+	}
+	if _, err := FileSecrets(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("FileSecrets() expected error for missing file")
+	}
+}
+
+func TestFileSecrets_reload(t *testing.T) {
+	defer func(interval time.Duration) { fileCheckInterval = interval }(fileCheckInterval)
+	fileCheckInterval = 0
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	writeFile(t, path, `{"key1": "secret1"}`)
+	getSecret, err := FileSecrets(path)
+	if err != nil {
+		t.Fatalf("FileSecrets() error = %v", err)
+	}
+	writeFile(t, path, `{"key1": "rotated1", "key2": "secret2"}`)
+	if got, _ := getSecret(context.Background(), "key2"); got != "secret2" {
+		t.Errorf("FileSecrets() got = %q after reload, want secret2", got)
+	}
+	writeFile(t, path, `{"key1": `)
+	if got, _ := getSecret(context.Background(), "key1"); got != "rotated1" {
+		t.Errorf("FileSecrets() got = %q after invalid reload, want previous secret rotated1", got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	// modification time granularity can be coarse, move it forward to make sure the change is detected
+	future := time.Now().Add(time.Duration(len(content)) * time.Second)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+}
+
+func TestDirectorySecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "key1"), "secret1\n")
+	writeFile(t, filepath.Join(dir, "key2"), "secret2\r\n")
+	writeFile(t, filepath.Join(dir, ".hidden"), "hidden")
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatalf("os.Mkdir() error = %v", err)
+	}
+	getSecret, err := DirectorySecrets(dir)
+	if err != nil {
+		t.Fatalf("DirectorySecrets() error = %v", err)
+	}
+	want := map[string]string{"key1": "secret1", "key2": "secret2", ".hidden": "", "nested": "", "unknown": ""}
+	for keyID, secret := range want {
+		if got, _ := getSecret(context.Background(), keyID); got != secret {
+			t.Errorf("DirectorySecrets()(%q) got = %q, want %q", keyID, got, secret)
+		}
+	}
+	if _, err = DirectorySecrets(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("DirectorySecrets() expected error for missing directory")
+	}
+}
+
+func TestDirectorySecrets_kubernetes(t *testing.T) {
+	defer func(interval time.Duration) { fileCheckInterval = interval }(fileCheckInterval)
+	fileCheckInterval = 0
+	dir := t.TempDir()
+	// layout of a Kubernetes secret volume: key -> ..data/key, ..data -> ..<timestamp>
+	projectSecrets(t, dir, "..2023_07_01_12_00_00.1", map[string]string{"key1": "secret1\n"})
+	for _, key := range []string{"key1", "key2"} {
+		if err := os.Symlink(filepath.Join(kubernetesDataDir, key), filepath.Join(dir, key)); err != nil {
+			t.Fatalf("os.Symlink() error = %v", err)
+		}
+	}
+	getSecret, err := DirectorySecrets(dir)
+	if err != nil {
+		t.Fatalf("DirectorySecrets() error = %v", err)
+	}
+	if got, _ := getSecret(context.Background(), "key1"); got != "secret1" {
+		t.Errorf("DirectorySecrets() got = %q, want secret1", got)
+	}
+	if got, _ := getSecret(context.Background(), "key2"); got != "" {
+		t.Errorf("DirectorySecrets() got = %q for dangling symlink, want empty", got)
+	}
+	projectSecrets(t, dir, "..2023_07_01_13_00_00.2", map[string]string{"key1": "rotated1\n", "key2": "secret2\n"})
+	want := map[string]string{"key1": "rotated1", "key2": "secret2"}
+	for keyID, secret := range want {
+		if got, _ := getSecret(context.Background(), keyID); got != secret {
+			t.Errorf("DirectorySecrets()(%q) got = %q after update, want %q", keyID, got, secret)
+		}
+	}
+}
+
+// projectSecrets writes secrets to a timestamped directory and atomically points ..data to it, as Kubernetes does.
+func projectSecrets(t *testing.T, dir, timestamped string, secrets map[string]string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, timestamped), 0o700); err != nil {
+		t.Fatalf("os.Mkdir() error = %v", err)
+	}
+	for key, secret := range secrets {
+		writeFile(t, filepath.Join(dir, timestamped, key), secret)
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(timestamped, tmp); err != nil {
+		t.Fatalf("os.Symlink() error = %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, kubernetesDataDir)); err != nil {
+		t.Fatalf("os.Rename() error = %v", err)
+	}
+}
+
+func TestReloadCheck_due(t *testing.T) {
+	var check reloadCheck
+	var wg sync.WaitGroup
+	var due atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if check.due() {
+				due.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := due.Load(); got != 1 {
+		t.Errorf("due() got true %d times for concurrent calls, want once", got)
+	}
+	check.reset()
+	if check.due() {
+		t.Errorf("due() got true right after reset()")
+	}
+}
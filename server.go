@@ -2,16 +2,19 @@ package hmac
 
 import (
 	"context"
+	"slices"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// ErrUnauthorized is returned when the request is not authorized for any reason.
+// ErrUnauthorized is returned when the request is not authorized for any reason, except for the gRPC status errors of
+// the Authenticator of WithFallback.
 var ErrUnauthorized = status.Errorf(codes.Unauthenticated, "Unauthenticated")
 
 // ServerInterceptor that implements HMAC authentication for gRPC servers.
+// Methods may be added to the interface, implementations outside this module should embed a ServerInterceptor.
 type ServerInterceptor interface {
 	// StreamInterceptor a grpc.ServerOption that can be passed to grpc.NewServer
 	StreamInterceptor() grpc.ServerOption
@@ -21,13 +24,32 @@ type ServerInterceptor interface {
 	UnaryInterceptor() grpc.ServerOption
 	// UnaryServerInterceptor a grpc.UnaryServerInterceptor that authenticates methods with unary (proto message) requests
 	UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error)
+	// ChainUnary a grpc.ServerOption adding UnaryServerInterceptor to the chained unary interceptors of the server
+	ChainUnary() grpc.ServerOption
+	// ChainStream a grpc.ServerOption adding StreamServerInterceptor to the chained stream interceptors of the server
+	ChainStream() grpc.ServerOption
 	IgnoredMethods(methods ...string)
 	ClearIgnores()
+	// UseMethodOptions reads the (hmac.v1.auth) method option of the services registered with server
+	UseMethodOptions(server ServiceInfoProvider) error
 }
 
 type serverInterceptor struct {
 	auth   func(ctx context.Context, message string) error
 	ignore []string
+	// optional methods read by UseMethodOptions, replaced on each call.
+	optional []string
+	// newMessage returns the message of unary requests, defaults to NewMessage.
+	newMessage func(req interface{}, method string) (string, string, error)
+	// fallback authenticates requests without or in addition to HMAC.
+	fallback *fallback
+	// forwarded trusts key ids forwarded by a HTTP gateway as Identity.
+	forwarded bool
+	// scopes of methods read by UseMethodOptions, checked with getScopes.
+	scopes    map[string][]string
+	getScopes GetScopes
+	// dryRun lets requests failing authentication or authorization through.
+	dryRun *dryRun
 }
 
 // GetSecret is a function that returns the secret for a given keyId.
@@ -36,11 +58,30 @@ type serverInterceptor struct {
 type GetSecret func(ctx context.Context, keyId string) (secret string, err error)
 
 // NewServerInterceptor returns a new server interceptor that authenticates requests using GetSecret.
-func NewServerInterceptor(getSecret GetSecret) ServerInterceptor {
-	return &serverInterceptor{authForSecrets(getSecret), make([]string, 0)}
+// The Secret of each key id is cached until GetSecret returns a different secret.
+func NewServerInterceptor(getSecret GetSecret, opts ...Option) ServerInterceptor {
+	return newServerInterceptor(authForSecrets(getSecret, opts...), newOptions(opts...))
+}
+
+// NewSecretServerInterceptor returns a new server interceptor that authenticates requests using GetSecretKey.
+func NewSecretServerInterceptor(getSecretKey GetSecretKey, opts ...Option) ServerInterceptor {
+	return newServerInterceptor(authForSecretKeys(getSecretKey, opts...), newOptions(opts...))
+}
+
+func newServerInterceptor(auth func(ctx context.Context, message string) error, o options) *serverInterceptor {
+	return &serverInterceptor{
+		auth:       auth,
+		ignore:     make([]string, 0),
+		newMessage: o.newMessage,
+		fallback:   o.fallback,
+		forwarded:  o.forwarding != nil,
+		getScopes:  o.getScopes,
+		dryRun:     o.dryRun,
+	}
 }
 
 // StreamInterceptor a grpc.ServerOption that can be passed to grpc.NewServer.
+// gRPC allows only one such option, use ChainStream to combine it with other interceptors.
 func (s *serverInterceptor) StreamInterceptor() grpc.ServerOption {
 	return grpc.StreamInterceptor(s.StreamServerInterceptor)
 }
@@ -55,14 +96,22 @@ func (s *serverInterceptor) StreamServerInterceptor(srv interface{}, ss grpc.Ser
 	if err != nil {
 		return err
 	}
-	if err = s.auth(ss.Context(), message); err != nil {
+	ctx, err := s.authenticate(ss.Context(), message)
+	if err != nil {
 		logger.Printf("auth error on streaming method %s: %q", info.FullMethod, err)
-		return ErrUnauthorized
+		if !s.dryRun.allows(ss.Context(), info.FullMethod, err) {
+			return unauthorized(err)
+		}
+		return handler(srv, ss)
+	}
+	if err = s.authorize(ctx, info.FullMethod); err != nil && !s.dryRun.allows(ctx, info.FullMethod, err) {
+		return err
 	}
-	return handler(srv, ss)
+	return handler(srv, &identityServerStream{ss, ctx})
 }
 
 // UnaryInterceptor a grpc.ServerOption that can be passed to grpc.NewServer.
+// gRPC allows only one such option, use ChainUnary to combine it with other interceptors.
 func (s *serverInterceptor) UnaryInterceptor() grpc.ServerOption {
 	return grpc.UnaryInterceptor(s.UnaryServerInterceptor)
 }
@@ -73,15 +122,44 @@ func (s *serverInterceptor) UnaryServerInterceptor(ctx context.Context, req inte
 		logger.Printf("ignoring unary method %s", info.FullMethod)
 		return handler(ctx, req)
 	}
-	message, err := NewMessage(req, info.FullMethod)
+	message, err := s.message(req, info.FullMethod)
 	if err != nil {
 		return nil, err
 	}
-	if err = s.auth(ctx, message); err != nil {
+	authCtx, err := s.authenticate(ctx, message)
+	if err != nil {
 		logger.Printf("auth error on unary method %s: %q", info.FullMethod, err)
-		return nil, ErrUnauthorized
+		if !s.dryRun.allows(ctx, info.FullMethod, err) {
+			return nil, unauthorized(err)
+		}
+		return handler(ctx, req)
+	}
+	if err = s.authorize(authCtx, info.FullMethod); err != nil && !s.dryRun.allows(authCtx, info.FullMethod, err) {
+		return nil, err
+	}
+	return handler(authCtx, req)
+}
+
+func (s *serverInterceptor) message(req interface{}, method string) (string, error) {
+	if s.newMessage == nil {
+		return NewMessage(req, method)
 	}
-	return handler(ctx, req)
+	message, _, err := s.newMessage(req, method)
+	return message, err
+}
+
+// ChainUnary a grpc.ServerOption adding UnaryServerInterceptor to the chained unary interceptors of the server.
+// Unlike UnaryInterceptor it can be combined with other interceptors, which run in the order of the options passed to
+// grpc.NewServer.
+func (s *serverInterceptor) ChainUnary() grpc.ServerOption {
+	return grpc.ChainUnaryInterceptor(s.UnaryServerInterceptor)
+}
+
+// ChainStream a grpc.ServerOption adding StreamServerInterceptor to the chained stream interceptors of the server.
+// Unlike StreamInterceptor it can be combined with other interceptors, which run in the order of the options passed
+// to grpc.NewServer.
+func (s *serverInterceptor) ChainStream() grpc.ServerOption {
+	return grpc.ChainStreamInterceptor(s.StreamServerInterceptor)
 }
 
 // IgnoredMethods from authentication.
@@ -89,16 +167,12 @@ func (s *serverInterceptor) IgnoredMethods(methods ...string) {
 	s.ignore = append(s.ignore, methods...)
 }
 
-// ClearIgnores clears the ignored methods.
+// ClearIgnores clears the ignored methods, including those not requiring HMAC read by UseMethodOptions.
 func (s *serverInterceptor) ClearIgnores() {
 	s.ignore = make([]string, 0)
+	s.optional = nil
 }
 
 func (s *serverInterceptor) ignored(method string) bool {
-	for _, m := range s.ignore {
-		if m == method {
-			return true
-		}
-	}
-	return false
+	return slices.Contains(s.ignore, method) || slices.Contains(s.optional, method)
 }
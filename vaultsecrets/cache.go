@@ -0,0 +1,107 @@
+package vaultsecrets
+
+import (
+	"container/list"
+	"time"
+)
+
+// sweepInterval is how often expired entries are removed from a cache that is not full.
+const sweepInterval = time.Minute
+
+// cached is the secret of a key id and when it expires.
+type cached struct {
+	keyId   string
+	secret  string
+	expires time.Time
+}
+
+// cache is a least recently used cache of at most max key ids, not safe for concurrent use.
+type cache struct {
+	max     int
+	entries map[string]*list.Element
+	// order of use, the front is the most recently used entry.
+	order *list.List
+	swept time.Time
+}
+
+func newCache(max int) *cache {
+	return &cache{max: max, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get returns the entry of keyId, including an expired entry the caller is about to refresh.
+func (c *cache) get(keyId string) (cached, bool) {
+	elem, ok := c.entries[keyId]
+	if !ok {
+		return cached{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(cached), true //nolint:forcetypeassert
+}
+
+// put adds or replaces the entry of its key id, removing expired entries every sweepInterval or when the cache is full
+// and the least recently used entry if it is still full.
+func (c *cache) put(now time.Time, entry cached) {
+	if elem, ok := c.entries[entry.keyId]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	if len(c.entries) >= c.max || now.Sub(c.swept) >= sweepInterval {
+		c.sweep(now)
+	}
+	if len(c.entries) >= c.max {
+		c.remove(c.order.Back().Value.(cached).keyId) //nolint:forcetypeassert
+	}
+	c.entries[entry.keyId] = c.order.PushFront(entry)
+}
+
+// remove the entry of keyId, if any.
+func (c *cache) remove(keyId string) {
+	if elem, ok := c.entries[keyId]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, keyId)
+	}
+}
+
+// sweep removes the entries expired at now.
+func (c *cache) sweep(now time.Time) {
+	c.swept = now
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(cached); !now.Before(entry.expires) { //nolint:forcetypeassert
+			c.order.Remove(elem)
+			delete(c.entries, entry.keyId)
+		}
+		elem = next
+	}
+}
+
+// limiter is a token bucket allowing rate events per second with bursts of rate, not safe for concurrent use.
+// A limiter with a rate that is not positive allows all events.
+type limiter struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate int) *limiter {
+	return &limiter{rate: float64(rate), tokens: float64(rate)}
+}
+
+// allow reports whether an event at now is allowed, taking a token if it is.
+func (l *limiter) allow(now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	if elapsed := now.Sub(l.last); !l.last.IsZero() && elapsed > 0 {
+		l.tokens = min(l.rate, l.tokens+elapsed.Seconds()*l.rate)
+	}
+	if now.After(l.last) {
+		l.last = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,51 @@
+package vaultsecrets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := newCache(2)
+	c.put(now, cached{"key1", "secret1", now.Add(time.Minute)})
+	c.put(now, cached{"key2", "secret2", now.Add(time.Minute)})
+	if _, ok := c.get("key1"); !ok {
+		t.Fatal("get() key1 not cached")
+	}
+	c.put(now, cached{"key3", "secret3", now.Add(time.Minute)})
+	if _, ok := c.get("key2"); ok {
+		t.Error("get() least recently used key2 not evicted")
+	}
+	if entry, ok := c.get("key1"); !ok || entry.secret != "secret1" {
+		t.Errorf("get() key1 got = %v, %v", entry, ok)
+	}
+	c.put(now, cached{"key1", "rotated1", now.Add(time.Minute)})
+	if entry, _ := c.get("key1"); entry.secret != "rotated1" || len(c.entries) != 2 {
+		t.Errorf("put() did not replace key1, got = %v with %d entries", entry, len(c.entries))
+	}
+	c.put(now.Add(2*time.Minute), cached{"key4", "secret4", now.Add(3 * time.Minute)})
+	if len(c.entries) != 1 || c.order.Len() != 1 {
+		t.Errorf("put() did not sweep expired entries, got %d entries", len(c.entries))
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := newLimiter(2)
+	if !l.allow(now) || !l.allow(now) || l.allow(now) {
+		t.Error("allow() did not allow a burst of 2")
+	}
+	if !l.allow(now.Add(500*time.Millisecond)) || l.allow(now.Add(500*time.Millisecond)) {
+		t.Error("allow() did not refill 1 token in half a second")
+	}
+	if l.allow(now) {
+		t.Error("allow() refilled tokens when the clock went backwards")
+	}
+	unlimited := newLimiter(-1)
+	for i := 0; i < 10; i++ {
+		if !unlimited.allow(now) {
+			t.Fatal("allow() limited without rate")
+		}
+	}
+}
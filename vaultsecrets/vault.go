@@ -0,0 +1,305 @@
+// Package vaultsecrets provides a hmac.GetSecret reading secrets from the HashiCorp Vault KV version 2 secrets engine
+// using the Vault HTTP API.
+package vaultsecrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	hmac "github.com/yogeshlonkar/go-grpc-hmac"
+)
+
+const (
+	defaultMount        = "secret"
+	defaultPathTemplate = "hmac/{keyId}"
+	defaultField        = "secret"
+	defaultAppRoleMount = "approle"
+	defaultCacheTTL     = 5 * time.Minute
+	defaultMaxEntries   = 10000
+	defaultMissRate     = 100
+	// tokenRenewMargin is subtracted from the AppRole token lease to log in again before the token expires.
+	tokenRenewMargin = 10 * time.Second
+	// tokenRenewFraction limits tokenRenewMargin to this fraction of short leases.
+	tokenRenewFraction = 4
+)
+
+// ErrTooManyMisses is returned when key ids not in the cache are read from Vault faster than Config.MissesPerSecond.
+var ErrTooManyMisses = errors.New("vaultsecrets: too many reads of uncached key ids")
+
+// Config of the Vault secrets provider, Address and one of Token or AppRole are required.
+type Config struct {
+	// Address of Vault, e.g. https://vault.example.com:8200.
+	Address string
+	// Namespace sent as X-Vault-Namespace, optional.
+	Namespace string
+	// Token used to authenticate, ignored when AppRole is set.
+	Token string
+	// AppRole credentials used to log in and obtain a token.
+	AppRole *AppRole
+	// Mount path of the KV version 2 secrets engine, defaults to "secret".
+	Mount string
+	// PathTemplate is the secret path within Mount, "{keyId}" is replaced by the path escaped key id.
+	// Defaults to "hmac/{keyId}".
+	PathTemplate string
+	// Field of the secret data holding the HMAC secret, defaults to "secret".
+	Field string
+	// CacheTTL is how long secrets, including unknown key ids, are cached when Vault returns no lease duration.
+	// Defaults to 5 minutes, a negative value disables caching.
+	CacheTTL time.Duration
+	// MaxCacheEntries is the number of key ids with a secret, and separately of unknown key ids, that are cached. The
+	// least recently used key ids are evicted first. Defaults to 10000.
+	MaxCacheEntries int
+	// MissesPerSecond limits the reads of key ids that are not cached, in bursts of up to MissesPerSecond. Key ids
+	// come from the incoming metadata, so this bounds the Vault requests a client can cause by sending unknown key ids.
+	// Further reads fail with ErrTooManyMisses. With caching disabled every read is limited. Defaults to 100, a negative
+	// value disables the limit.
+	MissesPerSecond int
+	// HTTPClient used for requests, defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Clock used for cache expiry, defaults to time.Now.
+	Clock hmac.Clock
+}
+
+// AppRole credentials for the AppRole auth method.
+type AppRole struct {
+	RoleID   string
+	SecretID string
+	// Mount path of the AppRole auth method, defaults to "approle".
+	Mount string
+}
+
+// New returns a hmac.GetSecret reading secrets from Vault.
+// Secrets are cached for the lease duration returned by Vault or Config.CacheTTL, see Config.MaxCacheEntries and
+// Config.MissesPerSecond for the limits applied to key ids read from requests. Key ids without a secret, or without
+// Config.Field in the secret data, return an empty string as required by hmac.GetSecret.
+func New(cfg Config) (hmac.GetSecret, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vaultsecrets: Address is required")
+	}
+	if cfg.Token == "" && cfg.AppRole == nil {
+		return nil, errors.New("vaultsecrets: Token or AppRole is required")
+	}
+	if !strings.Contains(cfg.PathTemplate, "{keyId}") && cfg.PathTemplate != "" {
+		return nil, errors.New("vaultsecrets: PathTemplate must contain {keyId}")
+	}
+	c := &client{cfg: cfg, token: cfg.Token}
+	c.setDefaults()
+	c.secrets, c.unknown = newCache(c.cfg.MaxCacheEntries), newCache(c.cfg.MaxCacheEntries)
+	c.misses = newLimiter(c.cfg.MissesPerSecond)
+	return c.getSecret, nil
+}
+
+type client struct {
+	cfg Config
+	mu  sync.Mutex
+	// secrets caches key ids with a secret, unknown key ids without, so unknown key ids cannot evict secrets.
+	secrets     *cache
+	unknown     *cache
+	misses      *limiter
+	token       string
+	tokenExpiry time.Time
+}
+
+func (c *client) setDefaults() {
+	c.cfg.Address = strings.TrimSuffix(c.cfg.Address, "/")
+	if c.cfg.Mount == "" {
+		c.cfg.Mount = defaultMount
+	}
+	if c.cfg.PathTemplate == "" {
+		c.cfg.PathTemplate = defaultPathTemplate
+	}
+	if c.cfg.Field == "" {
+		c.cfg.Field = defaultField
+	}
+	if c.cfg.CacheTTL == 0 {
+		c.cfg.CacheTTL = defaultCacheTTL
+	}
+	if c.cfg.MaxCacheEntries <= 0 {
+		c.cfg.MaxCacheEntries = defaultMaxEntries
+	}
+	if c.cfg.MissesPerSecond == 0 {
+		c.cfg.MissesPerSecond = defaultMissRate
+	}
+	if c.cfg.HTTPClient == nil {
+		c.cfg.HTTPClient = http.DefaultClient
+	}
+	if c.cfg.Clock == nil {
+		c.cfg.Clock = hmac.ClockFunc(time.Now)
+	}
+	if c.cfg.AppRole != nil {
+		appRole := *c.cfg.AppRole
+		if appRole.Mount == "" {
+			appRole.Mount = defaultAppRoleMount
+		}
+		c.cfg.AppRole = &appRole
+	}
+}
+
+func (c *client) getSecret(ctx context.Context, keyId string) (string, error) {
+	if keyId == "." || keyId == ".." {
+		return "", nil
+	}
+	now := c.cfg.Clock.Now()
+	if secret, ok, err := c.cached(keyId, now); ok || err != nil {
+		return secret, err
+	}
+	secret, ttl, err := c.read(ctx, keyId)
+	if err != nil {
+		return "", err
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		if secret == "" {
+			c.secrets.remove(keyId)
+			c.unknown.put(now, cached{keyId, secret, now.Add(ttl)})
+		} else {
+			c.unknown.remove(keyId)
+			c.secrets.put(now, cached{keyId, secret, now.Add(ttl)})
+		}
+		c.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// cached returns the secret of keyId if it is cached and not expired. Reads of key ids that are not cached at all
+// are limited by Config.MissesPerSecond, refreshing expired key ids is not.
+func (c *client) cached(keyId string, now time.Time) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.secrets.get(keyId)
+	if !ok {
+		entry, ok = c.unknown.get(keyId)
+	}
+	if ok && now.Before(entry.expires) {
+		return entry.secret, true, nil
+	}
+	if !ok && !c.misses.allow(now) {
+		return "", false, ErrTooManyMisses
+	}
+	return "", false, nil
+}
+
+// kvResponse is the response of a KV version 2 read.
+type kvResponse struct {
+	LeaseDuration int `json:"lease_duration"`
+	Data          struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// read returns the secret of keyId and how long it can be cached, retrying once with a new AppRole token.
+func (c *client) read(ctx context.Context, keyId string) (string, time.Duration, error) {
+	path := "/v1/" + c.cfg.Mount + "/data/" + strings.ReplaceAll(c.cfg.PathTemplate, "{keyId}", url.PathEscape(keyId))
+	var res kvResponse
+	status, err := c.do(ctx, http.MethodGet, path, nil, &res)
+	if status == http.StatusForbidden && c.cfg.AppRole != nil {
+		c.mu.Lock()
+		c.tokenExpiry = time.Time{}
+		c.mu.Unlock()
+		status, err = c.do(ctx, http.MethodGet, path, nil, &res)
+	}
+	if status == http.StatusNotFound {
+		return "", c.cfg.CacheTTL, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	ttl := c.cfg.CacheTTL
+	if res.LeaseDuration > 0 {
+		ttl = time.Duration(res.LeaseDuration) * time.Second
+	}
+	secret, _ := res.Data.Data[c.cfg.Field].(string)
+	return secret, ttl, nil
+}
+
+// do sends a request to Vault decoding the JSON response into out, it returns the HTTP status code if one was received.
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	token, err := c.authToken(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return c.send(ctx, method, path, token, body, out)
+}
+
+func (c *client) send(ctx context.Context, method, path, token string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.Address+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+	res, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("vaultsecrets: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&vaultErr)
+		return res.StatusCode, fmt.Errorf("vaultsecrets: %s %s returned %s %v", method, path, res.Status, vaultErr.Errors)
+	}
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return res.StatusCode, fmt.Errorf("vaultsecrets: failed to decode response of %s %s: %w", method, path, err)
+	}
+	return res.StatusCode, nil
+}
+
+// authToken returns the configured token or an AppRole token, logging in when it is missing or about to expire.
+func (c *client) authToken(ctx context.Context) (string, error) {
+	if c.cfg.AppRole == nil {
+		return c.cfg.Token, nil
+	}
+	now := c.cfg.Clock.Now()
+	c.mu.Lock()
+	token, expiry := c.token, c.tokenExpiry
+	c.mu.Unlock()
+	if token != "" && now.Before(expiry) {
+		return token, nil
+	}
+	var res struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": c.cfg.AppRole.RoleID, "secret_id": c.cfg.AppRole.SecretID}
+	if _, err := c.send(ctx, http.MethodPost, "/v1/auth/"+c.cfg.AppRole.Mount+"/login", "", body, &res); err != nil {
+		return "", err
+	}
+	if res.Auth.ClientToken == "" {
+		return "", errors.New("vaultsecrets: AppRole login returned no client token")
+	}
+	lease := time.Duration(res.Auth.LeaseDuration) * time.Second
+	expiry = now.Add(lease - min(tokenRenewMargin, lease/tokenRenewFraction))
+	if res.Auth.LeaseDuration == 0 {
+		// tokens without lease do not expire
+		expiry = time.Unix(1<<62, 0)
+	}
+	c.mu.Lock()
+	c.token, c.tokenExpiry = res.Auth.ClientToken, expiry
+	c.mu.Unlock()
+	return res.Auth.ClientToken, nil
+}
@@ -0,0 +1,268 @@
+package vaultsecrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yogeshlonkar/go-grpc-hmac/hmactest"
+	"github.com/yogeshlonkar/go-grpc-hmac/vaultsecrets"
+)
+
+// fakeVault is a httptest stand-in for the Vault KV version 2 and AppRole HTTP API.
+type fakeVault struct {
+	mu            sync.Mutex
+	secrets       map[string]map[string]interface{}
+	tokens        map[string]bool
+	leaseDuration int
+	tokenLease    int
+	reads         int
+	logins        int
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	t.Helper()
+	v := &fakeVault{
+		secrets: map[string]map[string]interface{}{
+			"/v1/secret/data/hmac/key1":   {"secret": "secret1"},
+			"/v1/kv/data/teams/key2/hmac": {"value": "secret2"},
+			"/v1/secret/data/hmac/key3":   {"other": "secret3"},
+		},
+		tokens:     map[string]bool{"root-token": true},
+		tokenLease: 3600,
+	}
+	srv := httptest.NewServer(v)
+	t.Cleanup(srv.Close)
+	return v, srv
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if r.URL.Path == "/v1/auth/approle/login" {
+		v.logins++
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret-id" {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		token := "approle-token-" + strings.Repeat("x", v.logins)
+		v.tokens[token] = true
+		writeJSON(w, http.StatusOK, map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": v.tokenLease}})
+		return
+	}
+	if !v.tokens[r.Header.Get("X-Vault-Token")] {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+	v.reads++
+	data, ok := v.secrets[r.URL.EscapedPath()]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"lease_duration": v.leaseDuration,
+		"data":           map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 1}},
+	})
+}
+
+func (v *fakeVault) revokeTokens() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tokens = map[string]bool{}
+}
+
+func (v *fakeVault) counts() (reads, logins int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.reads, v.logins
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestNew(t *testing.T) {
+	_, srv := newFakeVault(t)
+	tests := []struct {
+		name   string
+		cfg    vaultsecrets.Config
+		keyID  string
+		want   string
+		errMsg string
+	}{
+		{name: "Token", cfg: vaultsecrets.Config{Address: srv.URL, Token: "root-token"}, keyID: "key1", want: "secret1"},
+		{name: "PathTemplate", cfg: vaultsecrets.Config{Address: srv.URL + "/", Token: "root-token", Mount: "kv", PathTemplate: "teams/{keyId}/hmac", Field: "value"}, keyID: "key2", want: "secret2"},
+		{name: "UnknownKeyID", cfg: vaultsecrets.Config{Address: srv.URL, Token: "root-token"}, keyID: "unknown", want: ""},
+		{name: "MissingField", cfg: vaultsecrets.Config{Address: srv.URL, Token: "root-token"}, keyID: "key3", want: ""},
+		{name: "EscapedKeyID", cfg: vaultsecrets.Config{Address: srv.URL, Token: "root-token"}, keyID: "../hmac/key1", want: ""},
+		{name: "DotDotKeyID", cfg: vaultsecrets.Config{Address: srv.URL, Token: "root-token", PathTemplate: "{keyId}/key1"}, keyID: "..", want: ""},
+		{name: "AppRole", cfg: vaultsecrets.Config{Address: srv.URL, AppRole: &vaultsecrets.AppRole{RoleID: "role", SecretID: "secret-id"}}, keyID: "key1", want: "secret1"},
+		{name: "InvalidToken", cfg: vaultsecrets.Config{Address: srv.URL, Token: "invalid"}, keyID: "key1", errMsg: "permission denied"},
+		{name: "InvalidAppRole", cfg: vaultsecrets.Config{Address: srv.URL, AppRole: &vaultsecrets.AppRole{RoleID: "role", SecretID: "wrong"}}, keyID: "key1", errMsg: "invalid role or secret ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getSecret, err := vaultsecrets.New(tt.cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := getSecret(context.Background(), tt.keyID)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("GetSecret() error = %v, want %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("GetSecret() got = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_invalidConfig(t *testing.T) {
+	tests := map[string]vaultsecrets.Config{
+		"NoAddress":     {Token: "token"},
+		"NoAuth":        {Address: "http://vault"},
+		"NoKeyIDInPath": {Address: "http://vault", Token: "token", PathTemplate: "hmac/static"},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := vaultsecrets.New(cfg); err == nil {
+				t.Errorf("New() expected error")
+			}
+		})
+	}
+}
+
+func TestNew_cache(t *testing.T) {
+	vault, srv := newFakeVault(t)
+	clock := hmactest.NewClock(time.Now())
+	getSecret, err := vaultsecrets.New(vaultsecrets.Config{Address: srv.URL, Token: "root-token", CacheTTL: time.Minute, Clock: clock})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = getSecret(context.Background(), "key1")
+		_, _ = getSecret(context.Background(), "unknown")
+	}
+	if reads, _ := vault.counts(); reads != 2 {
+		t.Errorf("expected 2 reads within cache TTL got %d", reads)
+	}
+	clock.Advance(time.Minute)
+	_, _ = getSecret(context.Background(), "key1")
+	if reads, _ := vault.counts(); reads != 3 {
+		t.Errorf("expected read after cache TTL got %d reads", reads)
+	}
+	vault.leaseDuration = 3600
+	clock.Advance(time.Minute)
+	_, _ = getSecret(context.Background(), "key1")
+	clock.Advance(30 * time.Minute)
+	_, _ = getSecret(context.Background(), "key1")
+	if reads, _ := vault.counts(); reads != 4 {
+		t.Errorf("expected lease duration to be used as cache TTL got %d reads", reads)
+	}
+}
+
+func TestNew_appRoleRelogin(t *testing.T) {
+	vault, srv := newFakeVault(t)
+	clock := hmactest.NewClock(time.Now())
+	getSecret, err := vaultsecrets.New(vaultsecrets.Config{
+		Address:  srv.URL,
+		AppRole:  &vaultsecrets.AppRole{RoleID: "role", SecretID: "secret-id"},
+		CacheTTL: -1,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, _ = getSecret(context.Background(), "key1")
+	_, _ = getSecret(context.Background(), "key1")
+	if _, logins := vault.counts(); logins != 1 {
+		t.Errorf("expected token to be reused got %d logins", logins)
+	}
+	clock.Advance(time.Hour)
+	_, _ = getSecret(context.Background(), "key1")
+	if _, logins := vault.counts(); logins != 2 {
+		t.Errorf("expected login before token expiry got %d logins", logins)
+	}
+	vault.revokeTokens()
+	if got, err := getSecret(context.Background(), "key1"); err != nil || got != "secret1" {
+		t.Errorf("GetSecret() got = %q, %v after token revocation, want secret1", got, err)
+	}
+	if reads, logins := vault.counts(); logins != 3 || reads != 4 {
+		t.Errorf("expected login after permission denied got %d logins and %d reads", logins, reads)
+	}
+}
+
+func TestNew_shortTokenLease(t *testing.T) {
+	vault, srv := newFakeVault(t)
+	vault.tokenLease = 8
+	clock := hmactest.NewClock(time.Now())
+	getSecret, err := vaultsecrets.New(vaultsecrets.Config{
+		Address:  srv.URL,
+		AppRole:  &vaultsecrets.AppRole{RoleID: "role", SecretID: "secret-id"},
+		CacheTTL: -1,
+		Clock:    clock,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, _ = getSecret(context.Background(), "key1")
+	clock.Advance(5 * time.Second)
+	_, _ = getSecret(context.Background(), "key1")
+	if _, logins := vault.counts(); logins != 1 {
+		t.Errorf("expected token with lease shorter than the renew margin to be reused got %d logins", logins)
+	}
+	clock.Advance(2 * time.Second)
+	_, _ = getSecret(context.Background(), "key1")
+	if _, logins := vault.counts(); logins != 2 {
+		t.Errorf("expected login before token expiry got %d logins", logins)
+	}
+}
+
+func TestNew_unknownKeyIds(t *testing.T) {
+	vault, srv := newFakeVault(t)
+	clock := hmactest.NewClock(time.Now())
+	getSecret, err := vaultsecrets.New(vaultsecrets.Config{
+		Address:         srv.URL,
+		Token:           "root-token",
+		MaxCacheEntries: 2,
+		MissesPerSecond: 3,
+		Clock:           clock,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, _ = getSecret(context.Background(), "key1")
+	for _, keyID := range []string{"unknown1", "unknown2"} {
+		if got, err := getSecret(context.Background(), keyID); err != nil || got != "" {
+			t.Errorf("GetSecret(%q) got = %q, %v", keyID, got, err)
+		}
+	}
+	if _, err = getSecret(context.Background(), "unknown3"); !errors.Is(err, vaultsecrets.ErrTooManyMisses) {
+		t.Errorf("GetSecret() error = %v, want %v", err, vaultsecrets.ErrTooManyMisses)
+	}
+	if got, err := getSecret(context.Background(), "key1"); err != nil || got != "secret1" {
+		t.Errorf("GetSecret() got = %q, %v for cached key id while misses are limited, want secret1", got, err)
+	}
+	clock.Advance(time.Second)
+	_, _ = getSecret(context.Background(), "unknown3")
+	if got, err := getSecret(context.Background(), "key1"); err != nil || got != "secret1" {
+		t.Errorf("GetSecret() got = %q, %v, want secret1", got, err)
+	}
+	if reads, _ := vault.counts(); reads != 4 {
+		t.Errorf("expected unknown key ids not to evict cached secrets got %d reads", reads)
+	}
+}
@@ -0,0 +1,88 @@
+package hmac
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// TestVectors validates the library against testdata/vectors, the vectors are regenerated by hmactest.WriteVectors.
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/vectors/v1.json")
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	var set struct {
+		Version int `json:"version"`
+		Vectors []struct {
+			Name       string            `json:"name"`
+			KeyID      string            `json:"key_id"`
+			Secret     string            `json:"secret"`
+			Method     string            `json:"method"`
+			Request    json.RawMessage   `json:"request"`
+			Timestamp  int64             `json:"timestamp"`
+			DerivedKey string            `json:"derived_key"`
+			Message    string            `json:"message"`
+			Signature  string            `json:"signature"`
+			Metadata   map[string]string `json:"metadata"`
+		} `json:"vectors"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		t.Fatalf("failed to decode vectors: %v", err)
+	}
+	if set.Version != 1 {
+		t.Fatalf("unexpected vectors version %d", set.Version)
+	}
+	for _, v := range set.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			var req interface{}
+			if !bytes.Equal(v.Request, []byte("null")) {
+				req = v.Request
+			}
+			base, err := NewMessage(req, v.Method)
+			if err != nil {
+				t.Fatalf("NewMessage() error = %v", err)
+			}
+			message := base
+			var opts []Option
+			if v.Timestamp != 0 {
+				message = base + ";timestamp=" + strconv.FormatInt(v.Timestamp, 10)
+				opts = append(opts, WithClock(ClockFunc(func() time.Time { return time.Unix(v.Timestamp, 0) })), WithTimestamp(time.Minute))
+			}
+			key := v.Secret
+			if v.DerivedKey != "" {
+				date := v.Metadata["x-hmac-date"]
+				derived := DeriveKey(NewSecret([]byte(v.Secret)), date, v.Method)
+				if got := base64.StdEncoding.EncodeToString(derived); got != v.DerivedKey {
+					t.Errorf("DeriveKey() got = %v, want %v", got, v.DerivedKey)
+				}
+				key = string(derived)
+				now, _ := time.Parse("20060102", date)
+				opts = append(opts, WithClock(ClockFunc(func() time.Time { return now })), WithDerivedKeys())
+			}
+			if message != v.Message {
+				t.Errorf("NewMessage() got = %q, want %q", message, v.Message)
+			}
+			if got := String(key, v.Message); got != v.Signature {
+				t.Errorf("String() got = %v, want %v", got, v.Signature)
+			}
+			getSecret := func(_ context.Context, keyID string) (string, error) {
+				if keyID == v.KeyID {
+					return v.Secret, nil
+				}
+				return "", nil
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.New(v.Metadata))
+			if err = authForSecrets(getSecret, opts...)(ctx, base); err != nil {
+				t.Errorf("auth() error = %v", err)
+			}
+		})
+	}
+}